@@ -0,0 +1,346 @@
+package main
+
+import (
+	"cmp"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// flushDelay is how long an incomplete chunk waits for more lines before
+	// it is printed anyway. Keeps the last line of a quiet app from hanging.
+	flushDelay = 100 * time.Millisecond
+	// maxChunkLines caps how many lines are buffered for a single chunk.
+	maxChunkLines = 1000
+)
+
+var (
+	// traceStart matches the first line of common stack traces and panics.
+	traceStart = regexp.MustCompile(`^(panic: |fatal error: |goroutine \d+ .*\[.*\]:$|Traceback \(most recent call last\):$|` +
+		`Exception in thread |([\w$]+\.)+[\w$]*(Exception|Error)(: |$))`)
+	// traceLine matches lines inside a stack trace that are not indented, like
+	// Go function frames or the final line of a Python traceback.
+	traceLine = regexp.MustCompile(`^(goroutine \d+ .*\[.*\]:$|created by |\[signal |exit status \d+$|` +
+		`[\w./*()\[\]{},-]+\(.*\)$|[\w.]*(Error|Exception|Warning|Exit|Interrupt)(: .*)?$|` +
+		`During handling of the above exception|The above exception was the direct cause)`)
+	// continuationLine matches lines that always belong to the previous line.
+	continuationLine = regexp.MustCompile(`^(\s+\S|Caused by: |\.\.\. \d+ more)`)
+)
+
+// readChunks reads r line by line and calls handle with every complete chunk:
+// a single line, a JSON object spread across several lines or a block of
+// unstructured text such as a stack trace.
+//
+// Chunks are labeled with source. Lines of container logs are unwrapped first
+// if --envelope is set. Then prefixes of docker compose or foreman are taken
+// off, see chunker.
+func readChunks(r io.Reader, source string, cfg *Config, handle func(inputLine)) error {
+	lines := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- readLines(r, cfg.MaxLineBytes, func(line string) {
+			lines <- line
+		})
+
+		close(lines)
+	}()
+
+	unwrapper := newEnvelope(cfg.Envelope)
+	chunks := newChunker(source, handle)
+	timer := time.NewTimer(flushDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				timer.Stop()
+				unwrapper.rest(chunks.add)
+				chunks.flush()
+
+				return <-errs
+			}
+
+			text, stream, complete := unwrapper.unwrap(line)
+			if complete {
+				chunks.add(text, stream)
+			}
+
+			if chunks.pending() {
+				timer.Reset(flushDelay)
+			}
+		case <-timer.C:
+			chunks.flush()
+		}
+	}
+}
+
+// chunker assembles the lines of every stream and service on their own, so
+// a stack trace stays in one piece even if other services write in between.
+// Chunks are passed on in the order their first lines came in.
+//
+// Chunks are labeled with their service, or with their stream if there's
+// neither source nor service.
+type chunker struct {
+	source   string
+	handle   func(inputLine)
+	services *prefixes
+	groups   map[chunkKey]*chunkGroup
+	keys     []chunkKey
+	// seq counts the lines.
+	seq int
+	// ready holds complete chunks that wait for earlier ones.
+	ready []readyChunk
+}
+
+// chunkKey is the stream and service of a line.
+type chunkKey struct {
+	stream  string
+	service string
+}
+
+type chunkGroup struct {
+	asm *assembler
+	// start is the number of the first line of the pending chunk.
+	start int
+}
+
+type readyChunk struct {
+	start int
+	line  inputLine
+}
+
+func newChunker(source string, handle func(inputLine)) *chunker {
+	return &chunker{source: source, handle: handle, services: newPrefixes(), groups: map[chunkKey]*chunkGroup{}}
+}
+
+// add feeds the next line of a stream into the chunker.
+func (c *chunker) add(text, stream string) {
+	c.seq++
+
+	text, service := c.services.cut(text)
+	key := chunkKey{stream: stream, service: service}
+
+	group, ok := c.groups[key]
+	if !ok {
+		group = &chunkGroup{asm: &assembler{}}
+		group.asm.emit = func(chunk string) {
+			c.ready = append(c.ready, readyChunk{start: group.start, line: c.label(chunk, key)})
+			// The current line starts the next chunk, if any.
+			group.start = c.seq
+		}
+
+		c.groups[key] = group
+		c.keys = append(c.keys, key)
+	}
+
+	if !group.asm.pending() {
+		group.start = c.seq
+	}
+
+	group.asm.add(text)
+	c.release()
+}
+
+func (c *chunker) label(chunk string, key chunkKey) inputLine {
+	label := c.source
+	if key.service != "" {
+		label = c.services.label(key.service)
+		if c.source != "" {
+			label = c.source + " " + label
+		}
+	}
+
+	return inputLine{text: chunk, source: cmp.Or(label, key.stream), stream: key.stream}
+}
+
+// pending reports whether lines are waiting for their chunk to complete.
+func (c *chunker) pending() bool {
+	return slices.ContainsFunc(c.keys, func(key chunkKey) bool { return c.groups[key].asm.pending() })
+}
+
+// flush passes on everything that is buffered.
+func (c *chunker) flush() {
+	for _, key := range c.keys {
+		c.groups[key].asm.flush()
+	}
+
+	c.release()
+}
+
+// release passes on the complete chunks that started before every pending
+// one.
+func (c *chunker) release() {
+	first := c.seq + 1
+
+	for _, key := range c.keys {
+		if group := c.groups[key]; group.asm.pending() {
+			first = min(first, group.start)
+		}
+	}
+
+	slices.SortStableFunc(c.ready, func(a, b readyChunk) int { return a.start - b.start })
+
+	n := 0
+	for n < len(c.ready) && c.ready[n].start < first {
+		c.handle(c.ready[n].line)
+		n++
+	}
+
+	c.ready = slices.Delete(c.ready, 0, n)
+}
+
+// assembler groups consecutive lines that belong together.
+type assembler struct {
+	emit func(string)
+
+	lines []string
+	json  bool
+	depth jsonDepth
+}
+
+// pending reports whether lines are waiting for their chunk to complete.
+func (a *assembler) pending() bool {
+	return len(a.lines) > 0
+}
+
+// add feeds the next line into the assembler.
+func (a *assembler) add(line string) {
+	switch {
+	case !a.pending():
+		a.start(line)
+	case a.json:
+		if !isJSONContinuation(line) {
+			a.flush()
+			a.start(line)
+
+			return
+		}
+
+		a.lines = append(a.lines, line)
+		a.depth.feed(line)
+
+		if a.depth.closed() || len(a.lines) >= maxChunkLines {
+			a.flush()
+		}
+	case isTraceContinuation(line):
+		a.lines = append(a.lines, line)
+
+		if len(a.lines) >= maxChunkLines {
+			a.flush()
+		}
+	default:
+		a.flush()
+		a.start(line)
+	}
+}
+
+// start begins a new chunk with line.
+func (a *assembler) start(line string) {
+	if strings.HasPrefix(strings.TrimSpace(line), "{") {
+		a.depth = jsonDepth{}
+		a.depth.feed(line)
+
+		if a.depth.closed() {
+			a.emit(line)
+
+			return
+		}
+
+		a.json = true
+		a.lines = []string{line}
+
+		return
+	}
+
+	// Only the start of a stack trace waits for the lines that follow, other
+	// text is passed on right away.
+	if !traceStart.MatchString(line) {
+		a.emit(line)
+
+		return
+	}
+
+	a.lines = []string{line}
+}
+
+// flush emits whatever is buffered as a single chunk.
+func (a *assembler) flush() {
+	if !a.pending() {
+		return
+	}
+
+	lines := a.lines
+	// Blank lines are only kept inside a block, never at its end.
+	for len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	a.emit(strings.Join(lines, "\n"))
+
+	a.lines = nil
+	a.json = false
+}
+
+// isTraceContinuation reports whether line continues the buffered stack trace.
+func isTraceContinuation(line string) bool {
+	if strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return false
+	}
+
+	return continuationLine.MatchString(line) || strings.TrimSpace(line) == "" || traceLine.MatchString(line)
+}
+
+// isJSONContinuation reports whether line may be part of a pretty-printed
+// JSON object. Such lines are indented or close an object or array.
+func isJSONContinuation(line string) bool {
+	if line == "" {
+		return false
+	}
+
+	switch line[0] {
+	case ' ', '\t', '}', ']', '"':
+		return true
+	default:
+		return false
+	}
+}
+
+// jsonDepth tracks the nesting of braces and brackets across lines, ignoring
+// those inside of strings.
+type jsonDepth struct {
+	depth    int
+	inString bool
+	escaped  bool
+	started  bool
+}
+
+func (d *jsonDepth) feed(line string) {
+	for i := range len(line) {
+		char := line[i]
+
+		switch {
+		case d.escaped:
+			d.escaped = false
+		case d.inString && char == '\\':
+			d.escaped = true
+		case char == '"':
+			d.inString = !d.inString
+		case d.inString:
+		case char == '{' || char == '[':
+			d.depth++
+			d.started = true
+		case char == '}' || char == ']':
+			d.depth--
+		}
+	}
+}
+
+// closed reports whether the outermost object has been closed.
+func (d *jsonDepth) closed() bool {
+	return d.started && d.depth <= 0
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAssembler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Single lines stay single lines",
+			input:    "{\"msg\":\"a\"}\nplain text\n{\"msg\":\"b\"}",
+			expected: []string{`{"msg":"a"}`, "plain text", `{"msg":"b"}`},
+		},
+		{
+			name:     "Pretty-printed JSON object",
+			input:    "{\n  \"msg\": \"a } b\",\n  \"list\": [\n    {\"x\": 1}\n  ]\n}\nafter",
+			expected: []string{"{\n  \"msg\": \"a } b\",\n  \"list\": [\n    {\"x\": 1}\n  ]\n}", "after"},
+		},
+		{
+			name:     "Unbalanced brace is given up on an unindented line",
+			input:    "{not json\nnext line",
+			expected: []string{"{not json", "next line"},
+		},
+		{
+			name: "Go panic",
+			input: "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:5 +0x1d\nexit status 2\n" +
+				"{\"msg\":\"restarted\"}",
+			expected: []string{
+				"panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:5 +0x1d\nexit status 2",
+				`{"msg":"restarted"}`,
+			},
+		},
+		{
+			name:  "Python traceback",
+			input: "Traceback (most recent call last):\n  File \"app.py\", line 1, in <module>\nValueError: bad\nplain text",
+			expected: []string{
+				"Traceback (most recent call last):\n  File \"app.py\", line 1, in <module>\nValueError: bad",
+				"plain text",
+			},
+		},
+		{
+			name:  "Java exception",
+			input: "java.lang.IllegalStateException: boom\n\tat com.foo.Bar.baz(Bar.java:10)\nCaused by: java.io.IOException\n\t... 3 more",
+			expected: []string{
+				"java.lang.IllegalStateException: boom\n\tat com.foo.Bar.baz(Bar.java:10)\nCaused by: java.io.IOException\n\t... 3 more",
+			},
+		},
+		{
+			name:     "Blank lines outside of traces are kept",
+			input:    "first\n\nsecond",
+			expected: []string{"first", "", "second"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var actual []string
+
+			asm := assembler{emit: func(chunk string) {
+				actual = append(actual, chunk)
+			}}
+
+			for line := range strings.SplitSeq(testCase.input, "\n") {
+				asm.add(line)
+			}
+
+			asm.flush()
+
+			if !slices.Equal(actual, testCase.expected) {
+				t.Errorf("chunks = %q; want %q", actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestAssemblerPassesTextOnRightAway(t *testing.T) {
+	t.Parallel()
+
+	var actual []string
+
+	asm := assembler{emit: func(chunk string) {
+		actual = append(actual, chunk)
+	}}
+
+	asm.add("plain text")
+
+	if asm.pending() || !slices.Equal(actual, []string{"plain text"}) {
+		t.Errorf("chunks = %q; want the line without waiting for the next one", actual)
+	}
+
+	asm.add("panic: boom")
+
+	if !asm.pending() {
+		t.Error("expected the start of a stack trace to wait for the next line")
+	}
+}
+
+func TestChunker(t *testing.T) {
+	t.Parallel()
+
+	input := []string{
+		"api-1     | panic: boom",
+		`worker-1  | level=info msg="in between"`,
+		"api-1     | ",
+		"api-1     | goroutine 1 [running]:",
+		`api-1     | {"msg":"after"}`,
+	}
+
+	var actual []string
+
+	chunks := newChunker("", func(line inputLine) {
+		actual = append(actual, line.source+"|"+line.text)
+	})
+
+	// The API writes an event first, so its stack trace loses the prefix as
+	// well.
+	chunks.add(`api-1     | {"msg":"ready"}`, "")
+
+	for _, line := range input {
+		chunks.add(line, "")
+	}
+
+	chunks.flush()
+
+	expected := []string{
+		`api-1    |{"msg":"ready"}`,
+		"api-1    |panic: boom\n\ngoroutine 1 [running]:",
+		`worker-1 |level=info msg="in between"`,
+		`api-1    |{"msg":"after"}`,
+	}
+
+	if !slices.Equal(actual, expected) {
+		t.Errorf("chunks = %q; want %q", actual, expected)
+	}
+}
@@ -11,19 +11,28 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/pterm/pterm"
 	"github.com/tidwall/pretty"
 )
 
-func prettyPrintJSON(entry map[string]any, cfg *Config) {
+func prettyPrintJSON(entry *object, source string, cfg *Config) {
 	// Remove properties if a user wants to hide them
 	hideProperties(entry, cfg.HiddenKeys...)
+	dropProperties(entry, nil, cfg.hide)
+
+	if cfg.format != nil && cfg.format.print(entry, source, cfg) {
+		return
+	}
 
 	// TIME
-	timeValue, _ := entry[cfg.TimeKey].(string)
-	timeColor := pterm.FgDarkGray
+	timeValue := entryTime(entry, cfg)
+	timeColor := cfg.theme.Time
 	formattedTime := formatTime(timeValue, cfg.TimeInputFormat, cfg.TimeOutputFormat)
 
 	var formattedTimeWithAlign string
@@ -34,36 +43,60 @@ func prettyPrintJSON(entry map[string]any, cfg *Config) {
 	}
 
 	// LEVEL
-	levelValue, ok := entry[cfg.LevelKey].(string)
-	if levelValue == "" || !ok {
+	levelValue := entryLevel(entry, cfg)
+	if levelValue == "" {
 		levelValue = "NO LEVEL"
 	}
 
-	formattedLevel, levelColor := formatLevel(levelValue, cfg.EmojiLevel)
+	formattedLevel, levelColor := formatLevel(levelValue, cfg)
 
 	// MESSAGE
-	messageValue, _ := entry[cfg.MessageKey].(string)
-	formattedMessage := levelColor.Sprint(messageValue)
+	messageValue, _ := getString(entry, cfg.MessageKey)
+	formattedMessage := cfg.highlight.apply(levelColor.Sprint(messageValue))
 
 	// OVERALL FORMAT of first line
-	fmt.Printf("%s%s %s\n", formattedTimeWithAlign, formattedLevel, formattedMessage)
+	headline := fmt.Sprintf("%s%s%s %s", formatSource(source, cfg), formattedTimeWithAlign, formattedLevel, formattedMessage)
 
 	// Remove standard properties to avoid duplication if we display them on the
 	// first line
 	keysToHide := []string{cfg.TimeKey, cfg.LevelKey, cfg.MessageKey}
+
+	// The stream of container logs is shown as the source already.
+	if stream, _ := getString(entry, streamKey); cfg.Envelope != envelopeNone && stream != "" && stream == source {
+		keysToHide = append(keysToHide, streamKey)
+	}
 	hideProperties(entry, keysToHide...)
 
-	lineColor := pterm.FgGray
+	if len(cfg.show) > 0 {
+		keepProperties(entry, nil, cfg.show)
+	}
+
+	keys := entry.Keys()
+	if cfg.SortKeys {
+		keys = slices.Sorted(slices.Values(keys))
+	}
+
+	if cfg.Layout == layoutCompact {
+		properties := formatCompactProperties(entry, keys, visibleWidth(headline), cfg)
+		fmt.Printf("%s%s\n%s", headline, properties, formatNewLine(cfg.EmptyLineStrategy, true))
+
+		return
+	}
+
+	fmt.Println(headline)
+
+	lineColor := cfg.theme.Gutter
 	// Add alignment
 	vertAlign := lineColor.Sprint("      ")
 
 	var logLines []string
 
 	// add extra fields if any
-	if len(entry) > 0 {
-		for key, value := range entry {
-			formattedKey := pterm.NewStyle(pterm.FgDefault).Sprint(key)
-			formattedValue := formatValue(value)
+	if len(keys) > 0 {
+		for _, key := range keys {
+			value, _ := entry.Get(key)
+			formattedKey := cfg.highlight.apply(cfg.theme.Key.Sprint(key))
+			formattedValue := cfg.highlight.apply(formatValue(value, cfg))
 			formattedValueLines := strings.Split(formattedValue, "\n")
 			logLines = append(logLines, fmt.Sprintf("%s   %s: %s", vertAlign, formattedKey, formattedValueLines[0]))
 
@@ -74,7 +107,7 @@ func prettyPrintJSON(entry map[string]any, cfg *Config) {
 	}
 
 	// Show a pretty vertical line if there's some properties (at least 3)
-	addBorder(logLines, vertAlign)
+	addBorder(logLines, vertAlign, cfg.theme)
 
 	// Maybe add an empty line after each event
 	fmt.Printf("%s", formatNewLine(cfg.EmptyLineStrategy, true))
@@ -82,132 +115,226 @@ func prettyPrintJSON(entry map[string]any, cfg *Config) {
 
 // levelInfo holds the display properties for a specific log level.
 type levelInfo struct {
-	Style     *pterm.Style
-	MainColor pterm.Color
-	Emoji     string
-	Text      string
-	Align     bool
+	Severity   int
+	Background paint
+	Foreground paint
+	MainColor  paint
+	Emoji      string
+	Text       string
 }
 
 // levelMap maps uppercase log level strings to their display properties.
 // It also includes common aliases like "WARN" for "WARNING".
+//
+// Severity orders the levels from least to most severe; aliases share the same
+// rank. Users can add levels or change these with level definitions, see
+// newLevelTable.
 var levelMap = map[string]levelInfo{
 	"TRACE": {
-		Align:     true,
-		Style:     pterm.NewStyle(pterm.BgBlue, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgBlue,
-		Emoji:     "🐾 ",
-		Text:      " TRACE ",
+		Severity:   10,
+		Background: ansi(pterm.FgBlue),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgBlue),
+		Emoji:      "🐾 ",
+		Text:       " TRACE ",
 	},
 	"DEBUG": {
-		Align:     true,
-		Style:     pterm.NewStyle(pterm.BgGreen, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgGreen,
-		Emoji:     "🦠 ",
-		Text:      " DEBUG ",
+		Severity:   20,
+		Background: ansi(pterm.FgGreen),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgGreen),
+		Emoji:      "🦠 ",
+		Text:       " DEBUG ",
 	},
 	"INFO": {
-		Align:     false,
-		Style:     pterm.NewStyle(pterm.BgBlue, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgDefault,
-		Emoji:     "ℹ️ ",
-		Text:      "  INFO  ",
+		Severity:   30,
+		Background: ansi(pterm.FgBlue),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgDefault),
+		Emoji:      "ℹ️ ",
+		Text:       "  INFO  ",
 	},
 	"WARN": {
-		Align:     false,
-		Style:     pterm.NewStyle(pterm.BgYellow, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgYellow,
-		Emoji:     "⚠️ ",
-		Text:      "  WARN  ",
+		Severity:   40,
+		Background: ansi(pterm.FgYellow),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgYellow),
+		Emoji:      "⚠️ ",
+		Text:       "  WARN  ",
 	},
 	"WARNING": {
-		Align:     true,
-		Style:     pterm.NewStyle(pterm.BgYellow, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgYellow,
-		Emoji:     "⚠️ ",
-		Text:      "WARNING",
+		Severity:   40,
+		Background: ansi(pterm.FgYellow),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgYellow),
+		Emoji:      "⚠️ ",
+		Text:       "WARNING",
 	},
 	"ERROR": {
-		Align:     true,
-		Style:     pterm.NewStyle(pterm.BgRed, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgRed,
-		Emoji:     "❌ ",
-		Text:      " ERROR ",
+		Severity:   50,
+		Background: ansi(pterm.FgRed),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgRed),
+		Emoji:      "❌ ",
+		Text:       " ERROR ",
 	},
 	"ERR": {
-		Align:     true,
-		Style:     pterm.NewStyle(pterm.BgRed, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgRed,
-		Emoji:     "❌ ",
-		Text:      "  ERR  ",
+		Severity:   50,
+		Background: ansi(pterm.FgRed),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgRed),
+		Emoji:      "❌ ",
+		Text:       "  ERR  ",
 	},
 	"FATAL": {
-		Align:     true,
-		Style:     pterm.NewStyle(pterm.BgRed, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgMagenta,
-		Emoji:     "❌ ",
-		Text:      " FATAL ",
+		Severity:   60,
+		Background: ansi(pterm.FgRed),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgMagenta),
+		Emoji:      "❌ ",
+		Text:       " FATAL ",
 	},
 	"CRITICAL": {
-		Align:     false,
-		Style:     pterm.NewStyle(pterm.BgRed, pterm.FgBlack, pterm.Bold),
-		MainColor: pterm.FgMagenta,
-		Emoji:     "❌ ",
-		Text:      "CRITICAL",
+		Severity:   60,
+		Background: ansi(pterm.FgRed),
+		Foreground: ansi(pterm.FgBlack),
+		MainColor:  ansi(pterm.FgMagenta),
+		Emoji:      "❌ ",
+		Text:       "CRITICAL",
 	},
 }
 
-func prettyPrintBadJSON(line string, cfg *Config) {
-	fmt.Printf("🪵  %s\n%s", line, formatNewLine(cfg.EmptyLineStrategy, false))
+// entryLevel returns the raw level of an entry or an empty string if the entry
+// has none.
+func entryLevel(entry *object, cfg *Config) string {
+	levelValue, _ := lookup(entry, cfg.LevelKey)
+
+	return levelName(levelValue, cfg.LevelScheme)
+}
+
+// entryTime returns the raw time of an entry or an empty string if the entry
+// has none. Numeric timestamps are returned in their decimal representation.
+func entryTime(entry *object, cfg *Config) string {
+	value, _ := lookup(entry, cfg.TimeKey)
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// getString returns the value at path if it is a string.
+func getString(entry *object, path string) (string, bool) {
+	value, ok := lookup(entry, path)
+	if !ok {
+		return "", false
+	}
+
+	str, ok := value.(string)
+
+	return str, ok
+}
+
+// prettyPrintBadJSON prints unstructured text. Continuation lines of a
+// multi-line block, e.g. a stack trace, are indented below the first one.
+func prettyPrintBadJSON(text, source string, cfg *Config) {
+	text = strings.ReplaceAll(cfg.highlight.apply(text), "\n", "\n    ")
+	fmt.Printf("%s🪵  %s\n%s", formatSource(source, cfg), text, formatNewLine(cfg.EmptyLineStrategy, false))
+}
+
+// formatSource returns a colored label for the stream a line came from or an
+// empty string if there is none.
+func formatSource(source string, cfg *Config) string {
+	if source == "" {
+		return ""
+	}
+
+	var sourceColor style
+
+	switch source {
+	case streamStdout:
+		sourceColor = cfg.theme.Stdout
+	case streamStderr:
+		sourceColor = cfg.theme.Stderr
+	default:
+		sourceColor = sourceStyle(strings.TrimSpace(source), cfg.theme)
+	}
+
+	return sourceColor.Sprintf("%s ", source)
+}
+
+// sourceStyle picks a style of the theme for the label of a file. The same
+// name always gets the same style.
+func sourceStyle(name string, theme *Theme) style {
+	if len(theme.Sources) == 0 {
+		return theme.Stdout
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+
+	return theme.Sources[hash.Sum32()%uint32(len(theme.Sources))] //nolint:gosec // themes have a handful of styles
 }
 
 // formatLevel formts the log level
 //
+// Known levels are padded to the width of the widest level text, so messages
+// line up.
+//
 // Returns:
 // - uppercased and colorized string
 // - pterm color of level for further use.
-func formatLevel(level string, useEmoji bool) (string, pterm.Color) {
+func formatLevel(level string, cfg *Config) (string, paint) {
 	levelUppercase := strings.ToUpper(level)
 
-	if info, ok := levelMap[levelUppercase]; ok {
-		formattedLevel := info.Style.Sprint(info.Text)
-		if useEmoji && info.Emoji != "" {
+	if info, ok := cfg.levelTable.get(levelUppercase); ok {
+		label := style{fg: info.Foreground, bg: info.Background, attributes: []pterm.Color{pterm.Bold}}
+
+		formattedLevel := label.Sprint(info.Text)
+		if cfg.EmojiLevel && info.Emoji != "" {
 			formattedLevel = info.Emoji
 		}
 
-		if info.Align {
-			formattedLevel += " "
-		}
+		formattedLevel += strings.Repeat(" ", cfg.levelTable.width-utf8.RuneCountInString(info.Text))
 
 		return formattedLevel, info.MainColor
 	}
 
-	return levelUppercase, pterm.FgDefault
+	return levelUppercase, ansi(pterm.FgDefault)
 }
 
 // formatValue formats the value based on its type.
-func formatValue(value any) string {
+//
+// Objects keep the key order of the original entry unless cfg.SortKeys is set.
+func formatValue(value any, cfg *Config) string {
 	jsonBytes, err := json.Marshal(value)
 	if err != nil {
-		return pterm.FgRed.Sprint(fmt.Sprintf("%v", value))
+		return cfg.theme.Error.Sprint(fmt.Sprintf("%v", value))
 	}
 
-	beautiful := string(pretty.Color(pretty.Pretty(jsonBytes), jsonColor()))
+	opts := *pretty.DefaultOptions
+	opts.SortKeys = cfg.SortKeys
+
+	beautiful := string(pretty.Color(pretty.PrettyOptions(jsonBytes, &opts), jsonColor(cfg.theme)))
 
 	return strings.TrimSuffix(beautiful, "\n")
 }
 
-// jsonColor returns a pretty style for JSON output.
-func jsonColor() *pretty.Style {
+// jsonColor returns a pretty style for JSON output in the colors of the theme.
+func jsonColor(theme *Theme) *pretty.Style {
 	return &pretty.Style{
-		Key:      [2]string{"\x1B[39m", "\x1B[0m"},
-		String:   [2]string{"\x1B[32m", "\x1B[0m"},
-		Number:   [2]string{"\x1B[33m", "\x1B[0m"},
-		True:     [2]string{"\x1B[36m", "\x1B[0m"},
-		False:    [2]string{"\x1B[36m", "\x1B[0m"},
-		Null:     [2]string{"\x1B[2m", "\x1B[0m"},
-		Escape:   [2]string{"\x1B[35m", "\x1B[0m"},
-		Brackets: [2]string{"\x1B[1m", "\x1B[0m"},
+		Key:      jsonStyle(theme.JSON.Key),
+		String:   jsonStyle(theme.JSON.String),
+		Number:   jsonStyle(theme.JSON.Number),
+		True:     jsonStyle(theme.JSON.True),
+		False:    jsonStyle(theme.JSON.False),
+		Null:     jsonStyle(theme.JSON.Null),
+		Escape:   jsonStyle(theme.JSON.Escape),
+		Brackets: jsonStyle(theme.JSON.Brackets),
 		Append: func(dst []byte, cur byte) []byte {
 			if cur < ' ' && (cur != '\r' && cur != '\n' && cur != '\t' && cur != '\v') {
 				dst = append(dst, "\\u00"...)
@@ -221,6 +348,16 @@ func jsonColor() *pretty.Style {
 	}
 }
 
+// jsonStyle returns the escape sequences that wrap a JSON token in a style.
+func jsonStyle(s style) [2]string {
+	escape := s.escape()
+	if escape == "" || !pterm.PrintColor {
+		return [2]string{}
+	}
+
+	return [2]string{escape, "\x1B[0m"}
+}
+
 // hexp converts a byte to its hexadecimal representation.
 func hexp(p byte) byte {
 	switch {
@@ -249,10 +386,10 @@ func formatNewLine(strategy string, structured bool) string {
 	}
 }
 
-// hideProperty removes keys from a map.
-// The function modifies the map in place.
-func hideProperties(entry map[string]any, keys ...string) {
-	for _, k := range keys {
-		delete(entry, k)
+// hideProperty removes keys or nested paths from an entry.
+// The function modifies the entry in place.
+func hideProperties(entry *object, paths ...string) {
+	for _, path := range paths {
+		remove(entry, path)
 	}
 }
@@ -2,11 +2,18 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/pterm/pterm"
 	flag "github.com/spf13/pflag"
 )
 
@@ -17,27 +24,97 @@ var (
 	TreeState  = "dirty"
 )
 
+var (
+	ErrMissingCommand     = errors.New("missing command after \"--\"")
+	ErrUnexpectedArgument = errors.New("unexpected argument")
+)
+
 type Config struct {
-	TimeKey           string
-	MessageKey        string
-	LevelKey          string
-	EmptyLineStrategy string
-	EmojiLevel        bool
-	TimeInputFormat   string
-	TimeOutputFormat  string
-	HiddenKeys        []string
+	TimeKey            string
+	MessageKey         string
+	LevelKey           string
+	EmptyLineStrategy  string
+	EmojiLevel         bool
+	TimeInputFormat    string
+	TimeOutputFormat   string
+	HiddenKeys         []string
+	ShownKeys          []string
+	SortKeys           bool
+	InputFormat        string
+	MaxLineBytes       int
+	Profile            string
+	Detect             bool
+	DetectEvents       int
+	LevelScheme        string
+	MinLevel           string
+	MaxLevel           string
+	UnknownLevelPolicy string
+	UnstructuredPolicy string
+	LevelDefinitions   []string
+	ThemeName          string
+	ColorMode          string
+	Layout             string
+	Format             string
+	Where              []string
+	WhereNot           []string
+	Highlight          []string
+	Before             int
+	After              int
+	Context            int
+	Follow             bool
+	FromStart          bool
+	Merge              bool
+	NoSourceLabel      bool
+	Envelope           string
+
+	theme            *Theme
+	format           *formatTemplate
+	where            whereFilter
+	highlight        *highlighter
+	context          *eventContext
+	lineWidth        int
+	show             []keyPattern
+	hide             []keyPattern
+	levelTable       levelTable
+	levelFilter      levelFilter
+	levelDefinitions []levelDefinition
+	detector         *detector
+	files            []string
+	command          []string
+	configFiles      []string
+	printConfig      bool
 }
 
 func newConfig() *Config {
+	// The built-in theme and levels are always valid.
+	theme, _ := builtinTheme(defaultTheme)
+	levels, _ := newLevelTable(theme.levels)
+
 	return &Config{
-		TimeKey:           "time",
-		MessageKey:        "msg",
-		LevelKey:          "level",
-		EmptyLineStrategy: "always",
-		EmojiLevel:        false,
-		TimeInputFormat:   "RFC3339",
-		TimeOutputFormat:  "15:04:05.000",
-		HiddenKeys:        []string{},
+		TimeKey:            "time",
+		MessageKey:         "msg",
+		LevelKey:           "level",
+		EmptyLineStrategy:  "always",
+		EmojiLevel:         false,
+		TimeInputFormat:    "RFC3339",
+		TimeOutputFormat:   "15:04:05.000",
+		HiddenKeys:         []string{},
+		ShownKeys:          []string{},
+		UnknownLevelPolicy: policyShow,
+		UnstructuredPolicy: policyShow,
+		InputFormat:        inputAuto,
+		Envelope:           envelopeNone,
+		DetectEvents:       10,
+		LevelDefinitions:   []string{},
+		Where:              []string{},
+		WhereNot:           []string{},
+		Highlight:          []string{},
+		ThemeName:          defaultTheme,
+		ColorMode:          colorAuto,
+		Layout:             layoutExpanded,
+		theme:              theme,
+		levelTable:         levels,
+		context:            newEventContext(0, 0),
 	}
 }
 
@@ -47,17 +124,98 @@ func setupFlags(cfg *Config) {
 	flag.StringVarP(&cfg.LevelKey, "level", "l", cfg.LevelKey, "Name of the level property")
 	flag.StringVar(&cfg.EmptyLineStrategy, "linebreak", cfg.EmptyLineStrategy, "\"always\" | only after \"json\" | \"never\"")
 	flag.BoolVar(&cfg.EmojiLevel, "emoji", cfg.EmojiLevel, "Display levels as emoji instead of text")
+	flag.StringVar(
+		&cfg.LevelScheme,
+		"level-scheme",
+		cfg.LevelScheme,
+		"Meaning of numeric levels: \"pino\" | \"bunyan\" | \"syslog\" | \"otel\" | \"zap\"",
+	)
 	flag.StringVar(
 		&cfg.TimeInputFormat,
 		"time-in",
 		cfg.TimeInputFormat,
-		`Go time layout string or 'Unix' | 'UnixMilli' | 'UnixMicro'.`,
+		`Go time layout string or 'Unix' | 'UnixMilli' | 'UnixMicro' | 'UnixNano'.`,
 	)
 	flag.StringVar(&cfg.TimeOutputFormat, "time-out", cfg.TimeOutputFormat, "Print time in this format. Use Go time format string.")
 	flag.StringSliceVar(&cfg.HiddenKeys,
 		"hide",
 		cfg.HiddenKeys,
-		"Hide a property. Use the flag multiple times to hide more than one.")
+		"Hide a property, e.g. \"otel.*\" or \"re:_id$\". Use the flag multiple times to hide more than one.")
+	flag.StringSliceVar(&cfg.ShownKeys,
+		"show",
+		cfg.ShownKeys,
+		"Only show these properties besides time, level and message, e.g. \"user.id,http.*\". --hide wins over --show")
+	flag.StringVar(&cfg.Layout, "layout", cfg.Layout, "\"expanded\" properties below the message | \"compact\" key=value pairs on one line")
+	flag.StringVar(
+		&cfg.Format,
+		"format",
+		cfg.Format,
+		"Print events with this Go template, e.g. '{{.time}} [{{.request_id}}] {{.msg}} {{rest}}'",
+	)
+	flag.BoolVar(&cfg.SortKeys, "sort-keys", cfg.SortKeys, "Print properties in alphabetical order instead of the order they were logged in")
+	flag.StringVar(&cfg.MinLevel, "min-level", cfg.MinLevel, "Hide events below this level, e.g. \"info\"")
+	flag.StringVar(
+		&cfg.ThemeName,
+		"theme",
+		cfg.ThemeName,
+		"\"dark\" | \"light\" | \"solarized\" | \"high-contrast\" or the path of a theme file",
+	)
+	flag.StringVar(&cfg.ColorMode, "color", cfg.ColorMode, "\"auto\" (only on a terminal) | \"always\" | \"never\" color the output")
+	flag.StringVar(&cfg.MaxLevel, "max-level", cfg.MaxLevel, "Hide events above this level, e.g. \"warn\"")
+	flag.StringVar(
+		&cfg.UnknownLevelPolicy,
+		"unknown-level",
+		cfg.UnknownLevelPolicy,
+		"\"show\" | \"hide\" events with a missing or unknown level when filtering by level",
+	)
+	flag.StringArrayVar(
+		&cfg.LevelDefinitions,
+		"define-level",
+		cfg.LevelDefinitions,
+		"Add or change a level, e.g. \"name=NOTICE rank=35 bg=cyan aliases=NOTE\". Use the flag multiple times for more levels",
+	)
+	flag.StringVar(&cfg.UnstructuredPolicy, "unstructured", cfg.UnstructuredPolicy, "\"show\" | \"hide\" lines that are not JSON")
+	flag.StringArrayVar(
+		&cfg.Where,
+		"where",
+		cfg.Where,
+		"Only show events matching this expression, e.g. 'status_code >= 500 && path =~ \"^/api\"'",
+	)
+	flag.StringArrayVar(&cfg.WhereNot, "where-not", cfg.WhereNot, "Hide events matching this expression")
+	flag.StringArrayVar(
+		&cfg.Highlight,
+		"highlight",
+		cfg.Highlight,
+		"Highlight matches of this regular expression. Use the flag multiple times for more patterns",
+	)
+	flag.IntVarP(&cfg.Before, "before", "B", cfg.Before, "Show this many events before each event that passes the filters")
+	flag.IntVarP(&cfg.After, "after", "A", cfg.After, "Show this many events after each event that passes the filters")
+	flag.IntVarP(&cfg.Context, "context", "C", cfg.Context, "Show this many events before and after each event that passes the filters")
+	flag.StringVar(&cfg.InputFormat, "input", cfg.InputFormat, "\"auto\" | \"json\" | \"logfmt\"")
+	flag.StringVar(
+		&cfg.Envelope,
+		"envelope",
+		cfg.Envelope,
+		"Unwrap container logs: \"none\" | \"docker\" json-file logs | \"cri\" Kubernetes logs | \"auto\" both",
+	)
+	flag.BoolVarP(&cfg.Follow, "follow", "f", cfg.Follow, "Keep reading the files as they grow, like tail -F")
+	flag.BoolVar(&cfg.FromStart, "from-start", cfg.FromStart, "Start following files at their start instead of their end")
+	flag.BoolVar(
+		&cfg.Merge,
+		"merge",
+		cfg.Merge,
+		"Print the events of all files ordered by their time. Set options per file like \"api.log:time=ts,time-in=Unix\"",
+	)
+	flag.BoolVar(
+		&cfg.NoSourceLabel,
+		"no-source-label",
+		cfg.NoSourceLabel,
+		"Don't start the events of several files with the name of their file",
+	)
+	flag.IntVar(&cfg.MaxLineBytes, "max-line-bytes", cfg.MaxLineBytes, "Truncate lines longer than this many bytes. 0 means no limit")
+	flag.StringVar(&cfg.Profile, "profile", cfg.Profile, "Use the options of this profile from the config file")
+	flag.BoolVar(&cfg.Detect, "detect", cfg.Detect, "Guess time, level and message properties and the time format from the first events")
+	flag.IntVar(&cfg.DetectEvents, "detect-events", cfg.DetectEvents, "Number of events --detect looks at")
 }
 
 func setupCLI() *Config {
@@ -67,6 +225,7 @@ func setupCLI() *Config {
 	var showVersion bool
 
 	flag.BoolVarP(&showVersion, "version", "v", false, "Show version information")
+	flag.BoolVar(&cfg.printConfig, "print-config", false, "Show the effective configuration and exit")
 
 	flag.Usage = printHelp
 	flag.Parse()
@@ -76,9 +235,233 @@ func setupCLI() *Config {
 		os.Exit(0)
 	}
 
+	cfg.configFiles = findConfigFiles()
+
+	files, err := loadConfigFiles(cfg.configFiles)
+	if err == nil {
+		err = applyConfigFiles(files, cfg, flag.CommandLine)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	if cfg.Detect {
+		// Whatever was set by flags or config files wins over guesses.
+		cfg.detector = newDetector(cfg.DetectEvents, changedFlags(flag.CommandLine))
+	}
+
+	err = validateConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	err = parseArgs(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
 	return cfg
 }
 
+// parseArgs reads positional arguments. They are files to read, everything
+// after "--" is a command to run.
+func parseArgs(cfg *Config) error {
+	dash := flag.CommandLine.ArgsLenAtDash()
+	args := flag.Args()
+
+	if dash == -1 {
+		dash = len(args)
+	} else if dash == len(args) {
+		return ErrMissingCommand
+	}
+
+	if dash > 0 && dash < len(args) {
+		return fmt.Errorf("%w: %q: files can't be read while running a command", ErrUnexpectedArgument, args[0])
+	}
+
+	cfg.files = args[:dash]
+	cfg.command = args[dash:]
+
+	if cfg.Follow && (len(cfg.files) == 0 || slices.Contains(cfg.files, stdinName)) {
+		return ErrFollowNeedsFiles
+	}
+
+	if cfg.Merge && cfg.Follow {
+		return ErrMergeFollow
+	}
+
+	if cfg.Merge && len(cfg.files) == 0 {
+		return ErrMergeNeedsFiles
+	}
+
+	return nil
+}
+
+// validateConfig checks option values and prepares derived settings.
+func validateConfig(cfg *Config) error {
+	color, err := useColor(cfg.ColorMode, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if color {
+		pterm.EnableColor()
+	} else {
+		pterm.DisableColor()
+	}
+
+	theme, err := loadTheme(cfg.ThemeName)
+	if err != nil {
+		return err
+	}
+
+	cfg.theme = theme
+
+	cfg.levelTable, err = levelTableFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	levels, err := newLevelFilter(cfg)
+	if err != nil {
+		return err
+	}
+
+	cfg.levelFilter = levels
+
+	err = validateEnums(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.MaxLineBytes < 0 {
+		return fmt.Errorf("--max-line-bytes %d: %w: must not be negative", cfg.MaxLineBytes, ErrBadOptionValue)
+	}
+
+	// Only cut off values for people, files get everything.
+	if cfg.Layout == layoutCompact && isTerminal(os.Stdout) {
+		cfg.lineWidth = pterm.GetTerminalWidth()
+	}
+
+	err = buildFilters(cfg)
+	if err != nil {
+		return err
+	}
+
+	cfg.context, err = contextFromFlags(cfg)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateEnums checks the options that take one of a few names.
+func validateEnums(cfg *Config) error {
+	switch cfg.InputFormat {
+	case inputAuto, inputJSON, inputLogfmt:
+	default:
+		return fmt.Errorf("--input %q: %w", cfg.InputFormat, ErrUnknownInput)
+	}
+
+	switch cfg.Envelope {
+	case envelopeNone, envelopeDocker, envelopeCRI, envelopeAuto:
+	default:
+		return fmt.Errorf("--envelope %q: %w", cfg.Envelope, ErrUnknownEnvelope)
+	}
+
+	switch cfg.Layout {
+	case layoutExpanded, layoutCompact:
+	default:
+		return fmt.Errorf("--layout %q: %w", cfg.Layout, ErrUnknownLayout)
+	}
+
+	if _, ok := levelSchemes[cfg.LevelScheme]; !ok && cfg.LevelScheme != "" {
+		return fmt.Errorf("--level-scheme %q: %w", cfg.LevelScheme, ErrUnknownLevelScheme)
+	}
+
+	return nil
+}
+
+// buildFilters compiles the patterns of --show and --hide, the expressions of
+// --where, the highlights and the template of --format.
+func buildFilters(cfg *Config) error {
+	var err error
+
+	cfg.show, err = newKeyPatterns("show", cfg.ShownKeys)
+	if err != nil {
+		return err
+	}
+
+	cfg.hide, err = newKeyPatterns("hide", slices.DeleteFunc(slices.Clone(cfg.HiddenKeys), func(key string) bool {
+		return !isKeyPattern(key)
+	}))
+	if err != nil {
+		return err
+	}
+
+	cfg.where, err = newWhereFilter(cfg.Where, cfg.WhereNot)
+	if err != nil {
+		return err
+	}
+
+	cfg.highlight, err = newHighlighter(cfg.Highlight, cfg.theme)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Format != "" {
+		cfg.format, err = newFormatTemplate(cfg.Format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// levelTableFromConfig builds the levels. Definitions on the command line come
+// after those of config files, which come after the colors of the theme.
+func levelTableFromConfig(cfg *Config) (levelTable, error) {
+	defs := slices.Concat(cfg.theme.levels, cfg.levelDefinitions)
+
+	for _, spec := range cfg.LevelDefinitions {
+		def, err := parseLevelFlag(spec)
+		if err != nil {
+			return levelTable{}, err
+		}
+
+		defs = append(defs, def)
+	}
+
+	return newLevelTable(defs)
+}
+
+// contextFromFlags combines --before, --after and --context. --before and
+// --after win over --context when they are given, even as 0.
+func contextFromFlags(cfg *Config) (*eventContext, error) {
+	for i, value := range []int{cfg.Before, cfg.After, cfg.Context} {
+		if value < 0 {
+			return nil, fmt.Errorf("--%s %d: %w: must not be negative", []string{"before", "after", "context"}[i], value, ErrBadOptionValue)
+		}
+	}
+
+	before, after := cfg.Before, cfg.After
+	if !flag.CommandLine.Changed("before") {
+		before = cfg.Context
+	}
+
+	if !flag.CommandLine.Changed("after") {
+		after = cfg.Context
+	}
+
+	return newEventContext(before, after), nil
+}
+
 func printVersion() {
 	buildInfo, ok := debug.ReadBuildInfo()
 	if Version == "dev" && ok {
@@ -94,37 +477,243 @@ func printVersion() {
 func printHelp() {
 	fmt.Fprintf(os.Stderr, "axt | structured logs but forcibly gemütlich | %s\n\n", Version)
 	fmt.Fprintf(os.Stderr, "Usage:\n")
-	fmt.Fprintf(os.Stderr, "  axt [options]\n\n")
+	fmt.Fprintf(os.Stderr, "  axt [options] [file...]\n")
+	fmt.Fprintf(os.Stderr, "  axt [options] -- command [args...]\n\n")
+	fmt.Fprintf(os.Stderr, "Files compressed with gzip or bzip2 are decompressed, zstd needs the zstd command.\n\n")
 	fmt.Fprintf(os.Stderr, "Options:\n")
 	flag.PrintDefaults()
 }
 
+// scan renders the files one after the other, stdin if there are none. Events
+// are labeled with the name of their file if there is more than one.
+//
+// Like cat, axt carries on with the other files if one can't be read and exits
+// with 1 at the end.
 func scan(cfg *Config) {
-	scanner := bufio.NewScanner(os.Stdin)
+	files := cfg.files
+	if len(files) == 0 {
+		files = []string{stdinName}
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	sources := sourceLabels(files, cfg)
+	failed := false
 
-		var entry map[string]any
+	for i, name := range files {
+		err := scanFile(name, sources[i], cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading %s: %v\n", name, err)
+
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// sourceLabels returns the labels of events from files: their names padded to
+// the same width, or nothing for a single file or with --no-source-label.
+func sourceLabels(files []string, cfg *Config) []string {
+	sources := make([]string, len(files))
+	if len(files) < 2 || cfg.NoSourceLabel {
+		return sources
+	}
+
+	width := 0
+	for _, name := range files {
+		width = max(width, utf8.RuneCountInString(name))
+	}
+
+	for i, name := range files {
+		sources[i] = name + strings.Repeat(" ", width-utf8.RuneCountInString(name))
+	}
+
+	return sources
+}
+
+func scanFile(name, source string, cfg *Config) error {
+	input, err := openInput(name)
+	if err != nil {
+		return err
+	}
+
+	err = readChunks(input, source, cfg, func(line inputLine) {
+		render(line, cfg)
+	})
+
+	return errors.Join(err, input.Close())
+}
+
+// readLines calls handle for every line read from r.
+//
+// Lines can be of any length. If maxLineBytes is positive, longer lines are cut
+// off and end with a marker telling how much was dropped.
+func readLines(r io.Reader, maxLineBytes int, handle func(string)) error {
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := readLine(reader, maxLineBytes)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("can not read line: %w", err)
+		}
+
+		if line != "" || err == nil {
+			handle(line)
+		}
 
-		err := json.Unmarshal([]byte(line), &entry)
 		if err != nil {
-			prettyPrintBadJSON(line, cfg)
+			return nil
+		}
+	}
+}
+
+// readLine reads up to and including the next newline and returns the line
+// without its line ending. Returns io.EOF after the last line.
+func readLine(reader *bufio.Reader, maxLineBytes int) (string, error) {
+	var (
+		line    []byte
+		dropped int
+		err     error
+	)
+
+	for {
+		var fragment []byte
 
-			continue
+		fragment, err = reader.ReadSlice('\n')
+
+		if maxLineBytes > 0 && len(line)+len(fragment) > maxLineBytes {
+			keep := max(maxLineBytes-len(line), 0)
+			// Don't cut a multi-byte character in half.
+			for keep > 0 && !utf8.RuneStart(fragment[keep]) {
+				keep--
+			}
+
+			line = append(line, fragment[:keep]...)
+			dropped += len(fragment) - keep
+			maxLineBytes = len(line)
+		} else {
+			line = append(line, fragment...)
 		}
 
-		prettyPrintJSON(entry, cfg)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			break
+		}
 	}
 
-	err := scanner.Err()
+	if bytes.HasSuffix(line, []byte("\n")) {
+		line = line[:len(line)-1]
+	} else if dropped > 0 && err == nil {
+		dropped-- // the newline was counted as dropped
+	}
+
+	line = bytes.TrimSuffix(line, []byte("\r"))
+
+	if dropped > 0 {
+		line = fmt.Appendf(line, " … [truncated %d bytes]", dropped)
+	}
+
+	return string(line), err
+}
+
+// render decodes a line of input and prints it unless it is filtered out.
+func render(line inputLine, cfg *Config) {
+	entry, err := decodeInput(line, cfg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error reading input: %v\n", err)
-		os.Exit(1)
+		renderUnstructured(line, cfg)
+
+		return
+	}
+
+	if cfg.detector != nil {
+		cfg.detector.observe(entry, cfg)
+	}
+
+	renderEntry(entry, line.source, cfg)
+}
+
+// decodeInput decodes a chunk of input. The stream of an unwrapped container
+// log line is added as a property, unless the event has one already.
+func decodeInput(line inputLine, cfg *Config) (*object, error) {
+	entry, err := decodeLine(line.text, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := entry.Get(streamKey); line.stream != "" && !ok {
+		entry.Set(streamKey, line.stream)
+	}
+
+	return entry, nil
+}
+
+// renderUnstructured prints a line that isn't an event, unless they are
+// hidden.
+func renderUnstructured(line inputLine, cfg *Config) {
+	if cfg.UnstructuredPolicy != policyShow {
+		return
+	}
+
+	printLine := func() { prettyPrintBadJSON(line.text, line.source, cfg) }
+
+	// Text can't pass the level filter or --where, so with a filter it is only
+	// shown as context of the events that do.
+	if cfg.context.enabled() && (cfg.levelFilter.active || len(cfg.where) > 0) {
+		cfg.context.skip(printLine, cfg)
+
+		return
 	}
+
+	cfg.context.show(printLine, cfg)
+}
+
+// renderEntry prints a decoded event if it passes the filters, or keeps it as
+// context.
+func renderEntry(entry *object, source string, cfg *Config) {
+	printEntry := func() { prettyPrintJSON(entry, source, cfg) }
+
+	if !cfg.levelFilter.allows(entryLevel(entry, cfg)) || !cfg.where.allows(entry) {
+		cfg.context.skip(printEntry, cfg)
+
+		return
+	}
+
+	cfg.context.show(printEntry, cfg)
 }
 
 func main() {
 	cfg := setupCLI()
+
+	if cfg.printConfig {
+		printConfig(cfg, flag.CommandLine)
+
+		return
+	}
+
+	if len(cfg.command) > 0 {
+		code := run(cfg.command, cfg)
+		if code != 0 {
+			os.Exit(code)
+		}
+
+		return
+	}
+
+	if cfg.Merge {
+		merge(cfg)
+
+		return
+	}
+
+	if cfg.Follow {
+		err := follow(context.Background(), cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	scan(cfg)
 }
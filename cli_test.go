@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"regexp"
@@ -58,16 +60,25 @@ func captureOutput(t *testing.T, args []string, input string) string {
 		}
 	}()
 
-	main()
+	// Read while main() is running, large outputs would fill up the pipe
+	// otherwise.
+	var buf bytes.Buffer
 
-	wOut.Close()
+	done := make(chan struct{})
 
-	var buf bytes.Buffer
+	go func() {
+		defer close(done)
 
-	_, err = io.Copy(&buf, rOut)
-	if err != nil {
-		t.Errorf("failed to read from stdout pipe: %v", err)
-	}
+		_, err := io.Copy(&buf, rOut)
+		if err != nil {
+			t.Errorf("failed to read from stdout pipe: %v", err)
+		}
+	}()
+
+	main()
+
+	wOut.Close()
+	<-done
 
 	return buf.String()
 }
@@ -155,6 +166,348 @@ func TestMainFunction(t *testing.T) {
 		  12:05:55.123  DEBUG   Timestamp test
 		 `,
 		},
+		{
+			name:   "Min level hides less severe events",
+			args:   []string{"--min-level", "info"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"DEBUG","msg":"noise"}
+{"time":"2025-08-24T21:51:45.549Z","level":"WARNING","msg":"Disk almost full"}`,
+			expected: `
+ 21:51:45.549 WARNING  Disk almost full
+`,
+		},
+		{
+			name:   "Max level hides more severe events",
+			args:   []string{"--max-level", "debug"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"ERR","msg":"Boom"}
+{"time":"2025-08-24T21:51:45.549Z","level":"trace","msg":"Entering handler"}`,
+			expected: `
+ 21:51:45.549  TRACE   Entering handler
+`,
+		},
+		{
+			name:   "Unknown levels can be hidden when filtering",
+			args:   []string{"--min-level", "info", "--unknown-level", "hide"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","msg":"Who knows"}
+{"time":"2025-08-24T21:51:45.549Z","level":"NOTICE","msg":"Custom level"}
+{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Known level"}`,
+			expected: `
+ 21:51:45.549   INFO   Known level
+`,
+		},
+		{
+			name:   "Unknown levels are shown by default when filtering",
+			args:   []string{"--min-level", "error"},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","msg":"Who knows"}`,
+			expected: `
+ 21:51:45.549 NO LEVEL Who knows
+`,
+		},
+		{
+			name:   "Unstructured lines can be hidden",
+			args:   []string{"--unstructured", "hide"},
+			useUTC: true,
+			input: `something without proper JSON
+{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Structured"}`,
+			expected: `
+ 21:51:45.549   INFO   Structured
+`,
+		},
+		{
+			name:   "Properties keep the order they were logged in",
+			args:   []string{},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Ordered","zeta":1,"alpha":{"y":true,"b":false},"mid":"x"}`,
+			expected: `
+ 21:51:45.549   INFO   Ordered
+              ┌   zeta: 1
+              │   alpha: {
+              │     "y": true,
+              │     "b": false
+              │   }
+              └   mid: "x"
+`,
+		},
+		{
+			name:   "Sort keys alphabetically",
+			args:   []string{"--sort-keys"},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Sorted","zeta":1,"alpha":{"y":true,"b":false},"mid":"x"}`,
+			expected: `
+ 21:51:45.549   INFO   Sorted
+              ┌   alpha: {
+              │     "b": false,
+              │     "y": true
+              │   }
+              │   mid: "x"
+              └   zeta: 1
+`,
+		},
+		{
+			name:   "Logfmt input",
+			args:   []string{},
+			useUTC: true,
+			input:  `time=2025-08-24T21:51:45.549Z level=warn msg="Slow query" duration=2.5s table=users`,
+			expected: `
+ 21:51:45.549   WARN   Slow query
+                   duration: "2.5s"
+                   table: "users"
+`,
+		},
+		{
+			name:     "Logfmt is unstructured with JSON input",
+			args:     []string{"--input", "json"},
+			useUTC:   true,
+			input:    `level=info msg=hello`,
+			expected: `🪵  level=info msg=hello`,
+		},
+		{
+			name:   "Multi-line JSON object",
+			args:   []string{},
+			useUTC: true,
+			input: `{
+  "time": "2025-08-24T21:51:45.549Z",
+  "level": "INFO",
+  "msg": "Pretty printed",
+  "port": 8080
+}`,
+			expected: `
+ 21:51:45.549   INFO   Pretty printed
+                   port: 8080
+`,
+		},
+		{
+			name:   "Stack trace is a single unstructured block",
+			args:   []string{},
+			useUTC: true,
+			input: `panic: oh no
+
+goroutine 1 [running]:
+main.main()
+	/app/main.go:5 +0x1d`,
+			expected: `
+🪵  panic: oh no
+
+    goroutine 1 [running]:
+    main.main()
+    	/app/main.go:5 +0x1d
+`,
+		},
+		{
+			name:   "Nested keys for ECS",
+			args:   []string{"-t", "@timestamp", "-l", "log.level", "-m", "message", "--hide", "/log/origin/file/line"},
+			useUTC: true,
+			input:  `{"@timestamp":"2025-08-24T21:51:45.549Z","log":{"level":"ERROR","origin":{"file":{"line":12,"name":"a.go"}}},"message":"Nested"}`,
+			expected: `
+ 21:51:45.549  ERROR   Nested
+              ┌   log: {
+              │     "origin": {
+              │       "file": {
+              │         "name": "a.go"
+              │       }
+              │     }
+              └   }
+`,
+		},
+		{
+			name:   "Numeric pino levels",
+			args:   []string{"--time-in", "UnixMilli", "--min-level", "info", "--level-scheme", "pino"},
+			useUTC: true,
+			input: `{"level":20,"time":1756555555123,"msg":"Hidden debug"}
+{"level":40,"time":1756555555123,"msg":"Numeric warning"}`,
+			expected: `
+ 12:05:55.123   WARN   Numeric warning
+`,
+		},
+		{
+			name:   "Numeric syslog levels",
+			args:   []string{"--level-scheme", "syslog", "-l", "severity"},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","severity":3,"msg":"Syslog error"}`,
+			expected: `
+ 21:51:45.549   ERR    Syslog error
+`,
+		},
+		{
+			name:   "Custom levels",
+			args:   []string{"--define-level", "name=SUCCESS rank=35 color=green aliases=OK", "--min-level", "success"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"info","msg":"Hidden info"}
+{"time":"2025-08-24T21:51:45.549Z","level":"ok","msg":"Deployed"}`,
+			expected: `
+ 21:51:45.549  SUCCESS  Deployed
+`,
+		},
+		{
+			name:   "Theme",
+			args:   []string{"--theme", "high-contrast"},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","level":"debug","msg":"Themed","user":"c"}`,
+			expected: `
+ 21:51:45.549  DEBUG   Themed
+                   user: "c"
+`,
+		},
+		{
+			name:   "Compact layout",
+			args:   []string{"--layout", "compact", "--sort-keys"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"One line","user":{"name":"Ann","id":7},"path":"/a b","ok":true}
+{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Next"}`,
+			expected: `
+ 21:51:45.549   INFO   One line ok=true path="/a b" user={"id":7,"name":"Ann"}
+
+ 21:51:45.549   INFO   Next
+`,
+		},
+		{
+			name:   "Template format",
+			args:   []string{"--format", `{{formatTime .time}} {{formatLevel .level}}[{{.request_id}}] {{.msg}} {{rest}}`, "--linebreak", "never"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"ERROR","msg":"Failed","request_id":"r-1","code":1756555555123,"user":{"id":7}}
+not JSON`,
+			expected: `
+21:51:45.549  ERROR  [r-1] Failed code=1756555555123 user={"id":7}
+🪵  not JSON
+`,
+		},
+		{
+			name:   "Template format falls back on errors",
+			args:   []string{"--format", `{{.msg.text}}`, "--linebreak", "never"},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Not an object"}`,
+			expected: `
+ 21:51:45.549   INFO   Not an object
+`,
+		},
+		{
+			name:   "Where expressions",
+			args:   []string{"--where", `status >= 500 && path =~ "^/api"`, "--where-not", "user.id == 7"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Too good","status":200,"path":"/api/a"}
+{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Known user","status":500,"path":"/api/b","user":{"id":7}}
+{"time":"2025-08-24T21:51:45.549Z","level":"ERROR","msg":"Match","status":502,"path":"/api/c"}`,
+			expected: `
+ 21:51:45.549  ERROR   Match
+                   status: 502
+                   path: "/api/c"
+`,
+		},
+		{
+			name:   "Show selected properties",
+			args:   []string{"--show", "user.id,http.*", "--hide", "http.body"},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Request","user":{"id":7,"name":"Ann"},` +
+				`"http":{"method":"GET","body":"…"},"http.status":200,"trace_id":"abc"}`,
+			expected: `
+ 21:51:45.549   INFO   Request
+              ┌   user: {
+              │     "id": 7
+              │   }
+              │   http: {
+              │     "method": "GET"
+              │   }
+              └   http.status: 200
+`,
+		},
+		{
+			name:   "Hide patterns",
+			args:   []string{"--hide", "otel.*", "--hide", `re:_id$`},
+			useUTC: true,
+			input: `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Request","otel":{"scope":"http"},` +
+				`"otel.trace_id":"abc","resource":{"service_id":"s1","host":"h1"},"path":"/"}`,
+			expected: `
+ 21:51:45.549   INFO   Request
+              ┌   resource: {
+              │     "host": "h1"
+              │   }
+              └   path: "/"
+`,
+		},
+		{
+			name:   "Docker envelope",
+			args:   []string{"--envelope", "docker", "--linebreak", "never"},
+			useUTC: true,
+			input: `{"log":"{\"time\":\"2025-08-24T21:51:45.549Z\",\"level\":\"ERROR\",\"msg\":\"Unwrapped\",\"a\":1}\n","stream":"stderr"}
+{"log":"{\"time\":\"2025-08-24T21:51:45.549Z\",\"level\":\"INFO\",\"msg\":\"Split \",\"stream\":\"app\"","stream":"stdout"}
+{"log":"}\n","stream":"stdout"}`,
+			expected: `
+stderr 21:51:45.549  ERROR   Unwrapped
+                  a: 1
+stdout 21:51:45.549   INFO   Split
+                  stream: "app"
+`,
+		},
+		{
+			name:   "Docker compose prefixes",
+			args:   []string{"--linebreak", "never"},
+			useUTC: true,
+			input: `api-1     | {"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Listening","port":8080}
+worker-1  | time=2025-08-24T21:51:45.549Z level=warn msg="Queue is slow"`,
+			expected: `
+api-1     21:51:45.549   INFO   Listening
+                  port: 8080
+worker-1  21:51:45.549   WARN   Queue is slow
+`,
+		},
+		{
+			name:   "Context around filtered events",
+			args:   []string{"--min-level", "error", "--before", "1", "--after", "1", "--linebreak", "never"},
+			useUTC: true,
+			input: `{"level":"INFO","msg":"1"}
+{"level":"INFO","msg":"2"}
+{"level":"ERROR","msg":"3"}
+{"level":"INFO","msg":"4"}
+{"level":"INFO","msg":"5"}
+{"level":"INFO","msg":"6"}
+{"level":"ERROR","msg":"7"}`,
+			expected: `
+  INFO   2
+ ERROR   3
+  INFO   4
+--
+  INFO   6
+ ERROR   7
+`,
+		},
+		{
+			name:   "Text is only context of filtered events",
+			args:   []string{"--where", "status >= 500", "--before", "1", "--after", "1", "--hide", "status", "--linebreak", "never"},
+			useUTC: true,
+			input: `{"level":"INFO","msg":"1","status":200}
+plain text
+{"level":"INFO","msg":"3","status":200}
+{"level":"INFO","msg":"4","status":200}
+{"level":"ERROR","msg":"5","status":502}
+stack trace
+{"level":"INFO","msg":"7","status":200}`,
+			expected: `
+  INFO   4
+ ERROR   5
+🪵  stack trace
+`,
+		},
+		{
+			name:   "Unknown numeric level",
+			args:   []string{"--level-scheme", "pino"},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","level":35,"msg":"Custom pino level"}`,
+			expected: `
+ 21:51:45.549 35 Custom pino level
+`,
+		},
+		{
+			name:   "Numeric levels without a scheme",
+			args:   []string{},
+			useUTC: true,
+			input:  `{"time":"2025-08-24T21:51:45.549Z","level":"30","msg":"Not pino"}`,
+			expected: `
+ 21:51:45.549 30 Not pino
+`,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -179,26 +532,246 @@ func TestMainFunction(t *testing.T) {
 				return
 			}
 
-			// For the remaining lines (properties), their order isn't guaranteed.
-			// We just check that each expected property line is present in the actual output.
-			if len(expectedLines) > 1 {
-				for _, expectedLine := range expectedLines[1:] {
-					trimmedExpected := strings.TrimSpace(expectedLine)
-					found := false
+			// The remaining lines (properties) keep the order they were logged in.
+			if len(expectedLines) != len(actualLines) {
+				t.Errorf("Line count mismatch.\n--- Expected ---\n%s\n--- Actual ---\n%s", expectedClean, actualClean)
+
+				return
+			}
+
+			for i, expectedLine := range expectedLines[1:] {
+				trimmedExpected := strings.TrimSpace(expectedLine)
+				trimmedActual := strings.TrimSpace(actualLines[i+1])
+
+				if trimmedActual != trimmedExpected {
+					t.Errorf("Property line mismatch.\n--- Expected ---\n%s\n--- Actual Output ---\n%s", trimmedExpected, actualClean)
+				}
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestLongLines(t *testing.T) {
+	message := strings.Repeat("x", 10*1024*1024)
+	longLine := `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"` + message + `"}`
+	input := longLine + "\n" + `{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"still alive"}`
+
+	t.Run("10 MB line survives", func(t *testing.T) {
+		pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+		actual := stripAnsi(captureOutput(t, []string{}, input))
+
+		if !strings.Contains(actual, "INFO   "+message+"\n") {
+			t.Errorf("Output does not contain the full 10 MB message, got %d bytes", len(actual))
+		}
+
+		if !strings.Contains(actual, "INFO   still alive") {
+			t.Errorf("Line after the long line is missing")
+		}
+	})
+
+	t.Run("Long line is truncated with --max-line-bytes", func(t *testing.T) {
+		pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+		actual := stripAnsi(captureOutput(t, []string{"--max-line-bytes", "100"}, input))
+		expected := fmt.Sprintf("🪵  %s … [truncated %d bytes]", longLine[:100], len(longLine)-100)
+
+		if !strings.Contains(actual, expected) {
+			t.Errorf("Truncated line not found.\n--- Expected ---\n%s\n--- Actual ---\n%.300s", expected, actual)
+		}
+
+		if !strings.Contains(actual, "INFO   still alive") {
+			t.Errorf("Line after the long line is missing")
+		}
+	})
+
+	t.Run("Negative --max-line-bytes is rejected", func(t *testing.T) {
+		pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+
+		cfg := newConfig()
+		setupFlags(cfg)
+
+		err := pflag.CommandLine.Parse([]string{"--max-line-bytes", "-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = validateConfig(cfg)
+		if !errors.Is(err, ErrBadOptionValue) {
+			t.Errorf("validateConfig() error = %v; want ErrBadOptionValue", err)
+		}
+	})
+}
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestColorModes(t *testing.T) {
+	input := `{"time":"2025-08-24T21:51:45.549Z","level":"WARN","msg":"Colorful","a":1,"b":"x","c":null,"d":[true]}
+not JSON`
+
+	testCases := []struct {
+		name      string
+		args      []string
+		env       map[string]string
+		wantColor bool
+	}{
+		{name: "Never", args: []string{"--color", "never"}, wantColor: false},
+		{name: "Never wins over FORCE_COLOR", args: []string{"--color", "never"}, env: map[string]string{"FORCE_COLOR": "1"}, wantColor: false},
+		{name: "Always", args: []string{"--color", "always"}, wantColor: true},
+		{name: "Always wins over NO_COLOR", args: []string{"--color", "always"}, env: map[string]string{"NO_COLOR": "1"}, wantColor: true},
+		{name: "Auto without a terminal", args: []string{}, wantColor: false},
+		{name: "Auto with FORCE_COLOR", args: []string{}, env: map[string]string{"FORCE_COLOR": "1"}, wantColor: true},
+		{name: "NO_COLOR wins over FORCE_COLOR", args: []string{}, env: map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, wantColor: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", "")
+			t.Setenv("FORCE_COLOR", "")
+
+			for key, value := range testCase.env {
+				t.Setenv(key, value)
+			}
+
+			pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+			actual := captureOutput(t, testCase.args, input)
+
+			if !strings.Contains(actual, "Colorful") || !strings.Contains(actual, "not JSON") {
+				t.Fatalf("Output is incomplete:\n%s", actual)
+			}
+
+			hasColor := strings.Contains(actual, "\x1b[")
+			if hasColor != testCase.wantColor {
+				t.Errorf("Expected color %v, got output:\n%q", testCase.wantColor, actual)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestFileArguments(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	first, second := "a.log", "second.log"
+
+	err := os.WriteFile(first, []byte(`{"level":"INFO","msg":"from a"}`+"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(second, []byte(`{"level":"WARN","msg":"from second"}`+"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "One file",
+			args:     []string{first},
+			expected: []string{" INFO   from a"},
+		},
+		{
+			name:     "Files and stdin are labeled",
+			args:     []string{first, "-", second},
+			expected: []string{"a.log        INFO   from a", "-           DEBUG   from stdin", "second.log   WARN   from second"},
+		},
+		{
+			name:     "Labels can be turned off",
+			args:     []string{"--no-source-label", first, second},
+			expected: []string{" INFO   from a", " WARN   from second"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
 
-					for _, actualLine := range actualLines[1:] {
-						if strings.TrimSpace(actualLine) == trimmedExpected {
-							found = true
+			actual := stripAnsi(captureOutput(t, testCase.args, `{"level":"DEBUG","msg":"from stdin"}`))
+			lines := strings.Split(strings.TrimSpace(actual), "\n\n")
 
-							break
-						}
-					}
+			if len(lines) != len(testCase.expected) {
+				t.Fatalf("expected %d events, got:\n%s", len(testCase.expected), actual)
+			}
 
-					if !found {
-						t.Errorf("Expected property line not found in output.\n--- Missing Line ---\n%s\n--- Actual Output ---\n%s", trimmedExpected, actualClean)
-					}
+			for i, expected := range testCase.expected {
+				if strings.TrimSpace(lines[i]) != strings.TrimSpace(expected) {
+					t.Errorf("event %d.\n--- Expected ---\n%s\n--- Actual ---\n%s", i, expected, lines[i])
 				}
 			}
 		})
 	}
 }
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestMergeFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	files := map[string]string{
+		"a.log": `{"time":"2025-01-01T10:00:00Z","level":"INFO","msg":"a1"}
+{"time":"2025-01-01T10:00:02Z","level":"ERROR","msg":"a3"}
+goroutine 1 [running]:
+{"time":"2025-01-01T10:00:05Z","level":"INFO","msg":"a5"}
+`,
+		"zap.log": `starting up
+{"ts":1735725601.5,"level":"warn","msg":"b2","caller":"main.go:1"}
+{"ts":1735725603,"level":"info","msg":"b4","caller":"main.go:2"}
+`,
+	}
+
+	for name, content := range files {
+		err := os.WriteFile(name, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+	actual := stripAnsi(captureOutput(t, []string{"--merge", "--detect", "--linebreak", "never", "--hide", "caller", "a.log", "zap.log"}, ""))
+	expected := `a.log   10:00:00.000   INFO   a1
+zap.log 🪵  starting up
+zap.log 10:00:01.500   WARN   b2
+a.log   10:00:02.000  ERROR   a3
+a.log   🪵  goroutine 1 [running]:
+zap.log 10:00:03.000   INFO   b4
+a.log   10:00:05.000   INFO   a5
+`
+
+	if actual != expected {
+		t.Errorf("Events are not merged by time.\n--- Expected ---\n%s\n--- Actual ---\n%s", expected, actual)
+	}
+}
+
+func TestMergeFilesFormat(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	files := map[string]string{
+		"zap.log":  `{"ts":1735725600.5,"level":"info","msg":"zap"}` + "\n",
+		"pino.log": `{"time":1735725601000,"level":30,"msg":"pino"}` + "\n",
+	}
+
+	for name, content := range files {
+		err := os.WriteFile(name, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+	args := []string{"--merge", "--linebreak", "never", "--format", "{{formatTime .ts}}{{formatTime .time}} {{formatLevel .level}}",
+		"zap.log:time=ts,time-in=Unix", "pino.log:time-in=UnixMilli,level-scheme=pino"}
+	actual := stripAnsi(captureOutput(t, args, ""))
+	expected := `zap.log  10:00:00.500   INFO  
+pino.log 10:00:01.000   INFO  
+`
+
+	if actual != expected {
+		t.Errorf("--format doesn't use the keys and level scheme of each file.\n--- Expected ---\n%s\n--- Actual ---\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+var (
+	ErrUnknownColor     = errors.New("unknown color")
+	ErrUnknownColorMode = errors.New("unknown color mode")
+)
+
+// colorNames maps color names to their foreground colors. The background
+// colors are 10 above.
+var colorNames = map[string]pterm.Color{
+	"default":       pterm.FgDefault,
+	"black":         pterm.FgBlack,
+	"red":           pterm.FgRed,
+	"green":         pterm.FgGreen,
+	"yellow":        pterm.FgYellow,
+	"blue":          pterm.FgBlue,
+	"magenta":       pterm.FgMagenta,
+	"cyan":          pterm.FgCyan,
+	"white":         pterm.FgWhite,
+	"gray":          pterm.FgGray,
+	"grey":          pterm.FgGray,
+	"light-red":     pterm.FgLightRed,
+	"light-green":   pterm.FgLightGreen,
+	"light-yellow":  pterm.FgLightYellow,
+	"light-blue":    pterm.FgLightBlue,
+	"light-magenta": pterm.FgLightMagenta,
+	"light-cyan":    pterm.FgLightCyan,
+	"light-white":   pterm.FgLightWhite,
+}
+
+// xtermColors approximates the ANSI colors for fades, which need RGB values.
+var xtermColors = map[pterm.Color]pterm.RGB{
+	pterm.FgDefault:      {R: 150, G: 150, B: 150},
+	pterm.FgBlack:        {R: 0, G: 0, B: 0},
+	pterm.FgRed:          {R: 205, G: 0, B: 0},
+	pterm.FgGreen:        {R: 0, G: 205, B: 0},
+	pterm.FgYellow:       {R: 205, G: 205, B: 0},
+	pterm.FgBlue:         {R: 0, G: 0, B: 238},
+	pterm.FgMagenta:      {R: 205, G: 0, B: 205},
+	pterm.FgCyan:         {R: 0, G: 205, B: 205},
+	pterm.FgWhite:        {R: 229, G: 229, B: 229},
+	pterm.FgGray:         {R: 127, G: 127, B: 127},
+	pterm.FgLightRed:     {R: 255, G: 0, B: 0},
+	pterm.FgLightGreen:   {R: 0, G: 255, B: 0},
+	pterm.FgLightYellow:  {R: 255, G: 255, B: 0},
+	pterm.FgLightBlue:    {R: 92, G: 92, B: 255},
+	pterm.FgLightMagenta: {R: 255, G: 0, B: 255},
+	pterm.FgLightCyan:    {R: 0, G: 255, B: 255},
+	pterm.FgLightWhite:   {R: 255, G: 255, B: 255},
+}
+
+// attributeNames maps the names of text attributes to their SGR codes.
+var attributeNames = map[string]pterm.Color{
+	"bold":      pterm.Bold,
+	"dim":       pterm.Fuzzy,
+	"italic":    pterm.Italic,
+	"underline": pterm.Underscore,
+}
+
+// paint is a color: one of the 16 ANSI colors or an RGB color. The zero value
+// keeps the color of the terminal.
+type paint struct {
+	ansi pterm.Color
+	rgb  *pterm.RGB
+}
+
+// ansi returns the paint of a foreground color constant of pterm.
+func ansi(color pterm.Color) paint {
+	return paint{ansi: color}
+}
+
+// parsePaint returns the paint of a name like "red" or "light-blue" or of a hex
+// color like "#268bd2".
+func parsePaint(name string) (paint, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if hex, ok := strings.CutPrefix(name, "#"); ok {
+		value, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return paint{}, fmt.Errorf("%w %q, expected #rrggbb", ErrUnknownColor, name)
+		}
+
+		rgb := pterm.NewRGB(uint8(value>>16), uint8(value>>8), uint8(value)) //nolint:gosec // 24 bit
+
+		return paint{rgb: &rgb}, nil
+	}
+
+	color, ok := colorNames[name]
+	if !ok {
+		return paint{}, fmt.Errorf("%w %q", ErrUnknownColor, name)
+	}
+
+	return paint{ansi: color}, nil
+}
+
+// sgr returns the SGR parameters of the paint or an empty string for the zero
+// value.
+func (p paint) sgr(background bool) string {
+	switch {
+	case p.rgb != nil:
+		layer := 38
+		if background {
+			layer = 48
+		}
+
+		return fmt.Sprintf("%d;2;%d;%d;%d", layer, p.rgb.R, p.rgb.G, p.rgb.B)
+	case p.ansi == 0:
+		return ""
+	case background:
+		return strconv.Itoa(int(p.ansi) + 10)
+	default:
+		return strconv.Itoa(int(p.ansi))
+	}
+}
+
+// toRGB returns the paint as RGB value.
+func (p paint) toRGB() pterm.RGB {
+	if p.rgb != nil {
+		return *p.rgb
+	}
+
+	return xtermColors[p.ansi]
+}
+
+// Sprint colors the text in the paint.
+func (p paint) Sprint(a ...any) string {
+	return style{fg: p}.Sprint(a...)
+}
+
+// style is the look of an element: foreground and background paint and text
+// attributes like bold.
+type style struct {
+	fg         paint
+	bg         paint
+	attributes []pterm.Color
+}
+
+// parseStyle parses a style like "bold red", "black on #268bd2" or "dim". An
+// empty style keeps the look of the terminal.
+func parseStyle(spec string) (style, error) {
+	var s style
+
+	words := strings.Fields(spec)
+
+	for i := 0; i < len(words); i++ {
+		word := strings.ToLower(words[i])
+
+		if attribute, ok := attributeNames[word]; ok {
+			s.attributes = append(s.attributes, attribute)
+
+			continue
+		}
+
+		var (
+			p   paint
+			err error
+		)
+
+		if word == "on" && i+1 < len(words) {
+			i++
+			p, err = parsePaint(words[i])
+			s.bg = p
+		} else {
+			p, err = parsePaint(word)
+			s.fg = p
+		}
+
+		if err != nil {
+			return s, err
+		}
+	}
+
+	return s, nil
+}
+
+// escape returns the escape sequence that turns the style on or an empty string
+// if the style changes nothing.
+func (s style) escape() string {
+	codes := make([]string, 0, len(s.attributes)+2)
+
+	for _, attribute := range s.attributes {
+		codes = append(codes, strconv.Itoa(int(attribute)))
+	}
+
+	for i, p := range []paint{s.fg, s.bg} {
+		if code := p.sgr(i == 1); code != "" {
+			codes = append(codes, code)
+		}
+	}
+
+	if len(codes) == 0 {
+		return ""
+	}
+
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// Sprint styles the text.
+func (s style) Sprint(a ...any) string {
+	text := fmt.Sprint(a...)
+
+	escape := s.escape()
+	if escape == "" || !pterm.PrintColor {
+		return text
+	}
+
+	return escape + text + "\x1b[0m"
+}
+
+// Sprintf formats and styles the text.
+func (s style) Sprintf(format string, a ...any) string {
+	return s.Sprint(fmt.Sprintf(format, a...))
+}
+
+// useColor decides whether output to out is colored. In auto mode NO_COLOR
+// turns colors off, FORCE_COLOR turns them on and otherwise only terminals get
+// colors.
+func useColor(mode string, out *os.File) (bool, error) {
+	switch mode {
+	case colorAlways:
+		return true, nil
+	case colorNever:
+		return false, nil
+	case colorAuto:
+	default:
+		return false, fmt.Errorf("--color %q: %w", mode, ErrUnknownColorMode)
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false, nil
+	}
+
+	if force := os.Getenv("FORCE_COLOR"); force != "" {
+		return force != "0" && force != "false", nil
+	}
+
+	return isTerminal(out), nil
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	globalConfigFile  = "config.toml"
+	projectConfigFile = ".axt.toml"
+	profilesKey       = "profiles"
+	levelsKey         = "levels"
+)
+
+var (
+	ErrUnknownOption  = errors.New("unknown option")
+	ErrUnknownProfile = errors.New("unknown profile")
+	ErrBadOptionValue = errors.New("bad value")
+)
+
+// notConfigurable lists flags that only make sense on the command line.
+var notConfigurable = []string{"version", "print-config"}
+
+// configFile is a parsed config file.
+type configFile struct {
+	path string
+	tree map[string]any
+}
+
+// findConfigFiles returns the paths of existing config files, the global one
+// first: $XDG_CONFIG_HOME/axt/config.toml (or ~/.config/axt/config.toml) and
+// the .axt.toml closest to the working directory.
+func findConfigFiles() []string {
+	var paths []string
+
+	if dir := configDir(); dir != "" {
+		path := filepath.Join(dir, globalConfigFile)
+		if isFile(path) {
+			paths = append(paths, path)
+		}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return paths
+	}
+
+	for {
+		path := filepath.Join(dir, projectConfigFile)
+		if isFile(path) {
+			return append(paths, path)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return paths
+		}
+
+		dir = parent
+	}
+}
+
+// configDir returns $XDG_CONFIG_HOME/axt or ~/.config/axt. It is empty if
+// neither is known.
+func configDir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		configHome = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configHome, "axt")
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && info.Mode().IsRegular()
+}
+
+// loadConfigFiles reads and parses the config files at paths.
+func loadConfigFiles(paths []string) ([]configFile, error) {
+	files := make([]configFile, 0, len(paths))
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("can not read config: %w", err)
+		}
+
+		tree, err := parseTOML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		files = append(files, configFile{path: path, tree: tree})
+	}
+
+	return files, nil
+}
+
+// applyConfigFiles sets every option of the config files that was not given on
+// the command line. Later files override earlier ones and the selected profile
+// overrides the top level of every file.
+//
+// Config keys are the long names of the command-line flags, so the values go
+// through the same parsing as flags do.
+func applyConfigFiles(files []configFile, cfg *Config, flags *flag.FlagSet) error {
+	explicit := changedFlags(flags)
+
+	for _, file := range files {
+		err := applyConfigTable(file.tree, file.path, explicit, cfg, flags)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Profile == "" {
+		return nil
+	}
+
+	found := false
+
+	for _, file := range files {
+		profiles, _ := file.tree[profilesKey].(map[string]any)
+
+		profile, ok := profiles[cfg.Profile].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		found = true
+
+		origin := fmt.Sprintf("%s [%s.%s]", file.path, profilesKey, cfg.Profile)
+
+		err := applyConfigTable(profile, origin, explicit, cfg, flags)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("--profile %q: %w", cfg.Profile, ErrUnknownProfile)
+	}
+
+	return nil
+}
+
+// changedFlags returns the names of all flags that have been set.
+func changedFlags(flags *flag.FlagSet) map[string]bool {
+	changed := map[string]bool{}
+
+	flags.Visit(func(f *flag.Flag) {
+		changed[f.Name] = true
+	})
+
+	return changed
+}
+
+// applyConfigTable sets the options of a single table. Level definitions are
+// collected in cfg.
+func applyConfigTable(table map[string]any, origin string, explicit map[string]bool, cfg *Config, flags *flag.FlagSet) error {
+	for _, key := range slices.Sorted(maps.Keys(table)) {
+		value := table[key]
+
+		if key == profilesKey {
+			continue
+		}
+
+		if key == levelsKey {
+			defs, err := parseLevelTables(value, origin)
+			if err != nil {
+				return err
+			}
+
+			cfg.levelDefinitions = append(cfg.levelDefinitions, defs...)
+
+			continue
+		}
+
+		option := flags.Lookup(key)
+		if option == nil || slices.Contains(notConfigurable, key) {
+			return fmt.Errorf("%s: %w %q", origin, ErrUnknownOption, key)
+		}
+
+		if explicit[key] {
+			continue
+		}
+
+		values, err := configStrings(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", origin, key, err)
+		}
+
+		list, isList := option.Value.(flag.SliceValue)
+		if len(values) != 1 && !isList {
+			return fmt.Errorf("%s: %s: %w: expected a single value", origin, key, ErrBadOptionValue)
+		}
+
+		if isList {
+			// Set would add to the list of an earlier file, it's replaced.
+			err = list.Replace(values)
+		} else {
+			err = flags.Set(key, values[0])
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", origin, key, err)
+		}
+	}
+
+	return nil
+}
+
+// configStrings turns a config value into the strings a flag would receive.
+func configStrings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case int64:
+		return []string{strconv.FormatInt(v, 10)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+
+		for _, element := range v {
+			str, err := configStrings(element)
+			if err != nil || len(str) != 1 {
+				return nil, fmt.Errorf("%w: arrays may only contain strings, numbers and booleans", ErrBadOptionValue)
+			}
+
+			values = append(values, str[0])
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrBadOptionValue, value)
+	}
+}
+
+// printConfig prints the effective configuration as TOML.
+func printConfig(cfg *Config, flags *flag.FlagSet) {
+	fmt.Println("# Effective configuration of axt.")
+
+	for _, path := range cfg.configFiles {
+		fmt.Printf("# Loaded %s\n", path)
+	}
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if slices.Contains(notConfigurable, f.Name) {
+			return
+		}
+
+		switch f.Value.Type() {
+		case "bool", "int":
+			fmt.Printf("%s = %s\n", f.Name, f.Value.String())
+		case "stringSlice", "stringArray":
+			values, _ := flags.GetStringSlice(f.Name)
+			if f.Value.Type() == "stringArray" {
+				values, _ = flags.GetStringArray(f.Name)
+			}
+
+			quoted := make([]string, 0, len(values))
+
+			for _, v := range values {
+				quoted = append(quoted, strconv.Quote(v))
+			}
+
+			fmt.Printf("%s = [%s]\n", f.Name, strings.Join(quoted, ", "))
+		default:
+			fmt.Printf("%s = %s\n", f.Name, strconv.Quote(f.Value.String()))
+		}
+	})
+
+	// Definitions of the same level in several tables are printed as one.
+	var defs []levelDefinition
+
+	for _, def := range cfg.levelDefinitions {
+		i := slices.IndexFunc(defs, func(d levelDefinition) bool { return d.name == def.name })
+		if i == -1 {
+			defs = append(defs, levelDefinition{name: def.name, fields: maps.Clone(def.fields)})
+
+			continue
+		}
+
+		maps.Copy(defs[i].fields, def.fields)
+	}
+
+	for _, def := range defs {
+		fmt.Printf("\n[%s.%s]\n", levelsKey, strconv.Quote(def.name))
+
+		for _, key := range slices.Sorted(maps.Keys(def.fields)) {
+			switch value := def.fields[key]; key {
+			case "rank":
+				fmt.Printf("%s = %s\n", key, value)
+			case "aliases":
+				quoted := []string{}
+
+				for _, alias := range def.aliases() {
+					quoted = append(quoted, strconv.Quote(alias))
+				}
+
+				fmt.Printf("%s = [%s]\n", key, strings.Join(quoted, ", "))
+			default:
+				fmt.Printf("%s = %s\n", key, strconv.Quote(value))
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestConfigFiles(t *testing.T) {
+	configHome := t.TempDir()
+	project := t.TempDir()
+	workDir := filepath.Join(project, "cmd", "server")
+
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	files := map[string]string{
+		filepath.Join(configHome, "axt", "config.toml"): `
+emoji = true
+hide = ["trace_id"]
+
+[profiles.otel]
+time = "Timestamp"
+level = "SeverityText"
+message = "EventName"
+`,
+		filepath.Join(project, ".axt.toml"): `
+linebreak = "never"
+hide = ["span_id"]
+
+[profiles.otel]
+time-in = "UnixMilli"
+
+[levels.NOTICE]
+rank = 35
+aliases = ["NOTE"]
+`,
+	}
+
+	for path, content := range files {
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := os.MkdirAll(workDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(workDir)
+
+	testCases := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Global and project config are merged",
+			args:     []string{},
+			input:    `{"time":"2025-08-24T21:51:45.549Z","level":"WARN","msg":"Hi","span_id":"b","user":"c"}`,
+			expected: "21:51:45.549 ⚠️  Hi\n      user: \"c\"",
+		},
+		{
+			name:     "Lists of the project config replace the global ones",
+			args:     []string{},
+			input:    `{"time":"2025-08-24T21:51:45.549Z","level":"WARN","msg":"Hi","trace_id":"a","span_id":"b"}`,
+			expected: "21:51:45.549 ⚠️  Hi\n      trace_id: \"a\"",
+		},
+		{
+			name:     "Profile from both files",
+			args:     []string{"--profile", "otel"},
+			input:    `{"Timestamp":"1756555555123","SeverityText":"INFO","EventName":"Hi"}`,
+			expected: "12:05:55.123 ℹ️  Hi",
+		},
+		{
+			name:     "Flags override config files",
+			args:     []string{"--profile", "otel", "--emoji=false", "-m", "msg", "--hide", "user"},
+			input:    `{"Timestamp":"1756555555123","SeverityText":"INFO","msg":"Hi","trace_id":"a","user":"c"}`,
+			expected: "12:05:55.123   INFO   Hi\n      trace_id: \"a\"",
+		},
+		{
+			name: "Levels from config files and flags",
+			args: []string{"--emoji=false", "--min-level", "notice", "--define-level", "name=AUDIT rank=45 text=AUDIT"},
+			input: `{"level":"INFO","msg":"Hidden"}
+{"level":"note","msg":"Noted"}
+{"level":"audit","msg":"Audited"}`,
+			expected: " NOTICE  Noted\nAUDIT    Audited",
+		},
+		{
+			name:  "Print effective config",
+			args:  []string{"--profile", "otel", "--print-config"},
+			input: ``,
+			expected: strings.Join([]string{
+				`emoji = true`,
+				`hide = ["span_id"]`,
+				`linebreak = "never"`,
+				`message = "EventName"`,
+				`profile = "otel"`,
+				`time-in = "UnixMilli"`,
+				`[levels."NOTICE"]`,
+				`aliases = ["NOTE"]`,
+				`rank = 35`,
+			}, "\n"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+			actual := stripAnsi(captureOutput(t, testCase.args, testCase.input))
+
+			for _, expected := range strings.Split(testCase.expected, "\n") {
+				if !strings.Contains(actual, expected) {
+					t.Errorf("Expected line not found in output.\n--- Missing Line ---\n%s\n--- Actual Output ---\n%s", expected, actual)
+				}
+			}
+		})
+	}
+}
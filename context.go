@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// eventContext shows the events around selected events, like grep -B and -A.
+//
+// Events that aren't selected by the level filter or --where are kept in a ring
+// buffer of the last before events and printed if a selected event follows. The
+// after events behind a selected event are printed right away. Groups of events
+// that don't follow each other are divided by a separator.
+type eventContext struct {
+	before int
+	after  int
+	// seq counts the events, lastPrinted is the number of the last printed one.
+	seq         int
+	lastPrinted int
+	afterLeft   int
+	pending     []pendingEvent
+}
+
+// pendingEvent is a skipped event that may still be printed as context.
+type pendingEvent struct {
+	seq        int
+	printEvent func()
+}
+
+func newEventContext(before, after int) *eventContext {
+	return &eventContext{before: before, after: after}
+}
+
+// enabled reports whether events around selected events are shown.
+func (c *eventContext) enabled() bool {
+	return c.before > 0 || c.after > 0
+}
+
+// show prints a selected event and the context before it.
+func (c *eventContext) show(printEvent func(), cfg *Config) {
+	c.seq++
+
+	for _, event := range c.pending {
+		c.emit(event.seq, event.printEvent, cfg)
+	}
+
+	c.pending = c.pending[:0]
+	c.emit(c.seq, printEvent, cfg)
+	c.afterLeft = c.after
+}
+
+// skip holds back an event that isn't selected. It is printed if it belongs to
+// the context of a selected event.
+func (c *eventContext) skip(printEvent func(), cfg *Config) {
+	c.seq++
+
+	if c.afterLeft > 0 {
+		c.afterLeft--
+		c.emit(c.seq, printEvent, cfg)
+
+		return
+	}
+
+	if c.before == 0 {
+		return
+	}
+
+	if len(c.pending) == c.before {
+		c.pending = slices.Delete(c.pending, 0, 1)
+	}
+
+	c.pending = append(c.pending, pendingEvent{seq: c.seq, printEvent: printEvent})
+}
+
+// emit prints an event and a separator if events were left out since the last
+// one.
+func (c *eventContext) emit(seq int, printEvent func(), cfg *Config) {
+	if c.enabled() && c.lastPrinted > 0 && seq != c.lastPrinted+1 {
+		fmt.Println(cfg.theme.Gutter.Sprint("--"))
+	}
+
+	printEvent()
+
+	c.lastPrinted = seq
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+//nolint:paralleltest // meddles with os.Stdout and can't run in parallel
+func TestEventContext(t *testing.T) {
+	testCases := []struct {
+		name     string
+		before   int
+		after    int
+		events   string // "x" marks selected events, "." skipped ones
+		expected string // printed event numbers and separators
+	}{
+		{name: "No context", events: "..x..x", expected: "3 6"},
+		{name: "Before", before: 1, events: "..x...x", expected: "2 3 -- 6 7"},
+		{name: "After", after: 2, events: "x....x.", expected: "1 2 3 -- 6 7"},
+		{name: "Overlapping groups are joined", before: 2, after: 2, events: "...x..x...", expected: "2 3 4 5 6 7 8 9"},
+		{name: "Short start", before: 3, events: ".x", expected: "1 2"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			oldStdout := os.Stdout
+
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			os.Stdout = w
+
+			cfg := newConfig()
+			context := newEventContext(testCase.before, testCase.after)
+
+			for i, event := range testCase.events {
+				printEvent := func() { fmt.Println(i + 1) }
+
+				if event == 'x' {
+					context.show(printEvent, cfg)
+				} else {
+					context.skip(printEvent, cfg)
+				}
+			}
+
+			w.Close()
+
+			os.Stdout = oldStdout
+
+			output, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := strings.Join(strings.Fields(string(output)), " ")
+			if actual != testCase.expected {
+				t.Errorf("events %q printed %q; want %q", testCase.events, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestContextFromFlags(t *testing.T) {
+	testCases := []struct {
+		name   string
+		args   []string
+		before int
+		after  int
+	}{
+		{name: "No context", args: []string{}},
+		{name: "Context", args: []string{"--context", "3"}, before: 3, after: 3},
+		{name: "Before and after win", args: []string{"--context", "3", "--before", "1", "--after", "2"}, before: 1, after: 2},
+		{name: "Explicit zero wins", args: []string{"--context", "3", "--before", "0"}, before: 0, after: 3},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+
+			cfg := newConfig()
+			setupFlags(cfg)
+
+			err := pflag.CommandLine.Parse(testCase.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			context, err := contextFromFlags(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if context.before != testCase.before || context.after != testCase.after {
+				t.Errorf("%v gave %d before and %d after; want %d and %d",
+					testCase.args, context.before, context.after, testCase.before, testCase.after)
+			}
+		})
+	}
+}
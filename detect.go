@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// convention describes the keys a logging library or schema uses.
+type convention struct {
+	name    string
+	time    string
+	level   string
+	message string
+	// numericLevel is set if the level is a number instead of a name.
+	numericLevel bool
+	// marker is a key that is characteristic for the convention.
+	marker string
+}
+
+// conventions is the catalogue used by --detect. On a tie the first entry wins.
+var conventions = []convention{
+	{name: "slog", time: "time", level: "level", message: "msg"},
+	{name: "logrus", time: "time", level: "level", message: "msg", marker: "func"},
+	{name: "zap", time: "ts", level: "level", message: "msg", marker: "caller"},
+	{name: "zerolog", time: "time", level: "level", message: "message"},
+	{name: "pino", time: "time", level: "level", message: "msg", numericLevel: true},
+	{name: "bunyan", time: "time", level: "level", message: "msg", numericLevel: true, marker: "v"},
+	{name: "ECS", time: "@timestamp", level: "log.level", message: "message", marker: "ecs.version"},
+	{name: "OpenTelemetry", time: "Timestamp", level: "SeverityText", message: "Body", marker: "SeverityNumber"},
+	{name: "GCP", time: "timestamp", level: "severity", message: "message"},
+	{name: "GCP", time: "time", level: "severity", message: "message"},
+	{name: "Serilog", time: "@t", level: "@l", message: "@m"},
+	{name: "Serilog", time: "@t", level: "@l", message: "@mt"},
+}
+
+// timeLayouts are tried in order to find the TimeInputFormat of string
+// timestamps.
+var timeLayouts = []string{
+	"RFC3339",
+	"2006-01-02T15:04:05.999Z0700",
+	"2006-01-02 15:04:05.999",
+	"DateTime",
+	"RFC1123Z",
+	"RFC1123",
+}
+
+// detector guesses time, level and message keys from the first events.
+//
+// The guess is refined with every event until limit events have been seen, so
+// the first events don't have to wait for the decision. Keys set explicitly by
+// flags or config files are never touched.
+type detector struct {
+	limit    int
+	seen     int
+	scores   []int
+	best     int
+	explicit map[string]bool
+	// source names the input in messages if there is more than one.
+	source string
+}
+
+func newDetector(limit int, explicit map[string]bool) *detector {
+	return &detector{
+		limit:    limit,
+		scores:   make([]int, len(conventions)),
+		best:     -1,
+		explicit: explicit,
+	}
+}
+
+// observe scores an event and updates cfg with the best guess so far.
+func (d *detector) observe(entry *object, cfg *Config) {
+	if d.seen >= d.limit {
+		return
+	}
+
+	d.seen++
+
+	for i, conv := range conventions {
+		d.scores[i] += conv.score(entry)
+	}
+
+	best := 0
+
+	for i, score := range d.scores {
+		if score > d.scores[best] {
+			best = i
+		}
+	}
+
+	if d.scores[best] == 0 || best == d.best {
+		return
+	}
+
+	d.best = best
+	conv := conventions[best]
+
+	if !d.explicit["time"] {
+		cfg.TimeKey = conv.time
+	}
+
+	if !d.explicit["level"] {
+		cfg.LevelKey = conv.level
+	}
+
+	if !d.explicit["message"] {
+		cfg.MessageKey = conv.message
+	}
+
+	if _, ok := levelSchemes[conv.name]; ok && conv.numericLevel && !d.explicit["level-scheme"] {
+		cfg.LevelScheme = conv.name
+	}
+
+	if !d.explicit["time-in"] {
+		format, ok := detectTimeFormat(entryTime(entry, cfg))
+		if ok {
+			cfg.TimeInputFormat = format
+		}
+	}
+
+	prefix := "axt: "
+	if d.source != "" {
+		prefix += d.source + ": "
+	}
+
+	fmt.Fprintf(os.Stderr, "%sdetected %s (time %q as %s, level %q, message %q)\n",
+		prefix, conv.name, cfg.TimeKey, cfg.TimeInputFormat, cfg.LevelKey, cfg.MessageKey)
+}
+
+// score counts how well an entry matches the convention.
+func (c convention) score(entry *object) int {
+	score := 0
+
+	for _, key := range []string{c.time, c.level, c.message} {
+		if _, ok := lookup(entry, key); ok {
+			score++
+		}
+	}
+
+	if score == 0 {
+		return 0
+	}
+
+	if level, ok := lookup(entry, c.level); ok {
+		_, isNumber := level.(float64)
+		if isNumber == c.numericLevel {
+			score++
+		}
+	}
+
+	if c.marker != "" {
+		if _, ok := lookup(entry, c.marker); ok {
+			score++
+		}
+	}
+
+	return score
+}
+
+// detectTimeFormat guesses the TimeInputFormat of a timestamp.
+func detectTimeFormat(value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		// Tell the epoch units apart by their magnitude.
+		switch magnitude := math.Abs(number); {
+		case magnitude < 1e11:
+			return "Unix", true
+		case magnitude < 1e14:
+			return "UnixMilli", true
+		case magnitude < 1e17:
+			return "UnixMicro", true
+		default:
+			return "UnixNano", true
+		}
+	}
+
+	for _, name := range timeLayouts {
+		layout, ok := formats[name]
+		if !ok {
+			layout = name
+		}
+
+		_, err := time.Parse(layout, strings.TrimSpace(value))
+		if err == nil {
+			return name, true
+		}
+	}
+
+	return "", false
+}
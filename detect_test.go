@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDetectTimeFormat(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value    string
+		expected string
+		ok       bool
+	}{
+		{value: "1756555555", expected: "Unix", ok: true},
+		{value: "1756555555.123", expected: "Unix", ok: true},
+		{value: "1756555555123", expected: "UnixMilli", ok: true},
+		{value: "1756555555123456", expected: "UnixMicro", ok: true},
+		{value: "1756555555123456789", expected: "UnixNano", ok: true},
+		{value: "2025-08-24T21:51:45.549605+02:00", expected: "RFC3339", ok: true},
+		{value: "2025-08-24T21:51:45.549+0200", expected: "2006-01-02T15:04:05.999Z0700", ok: true},
+		{value: "2025-08-24 21:51:45.549", expected: "2006-01-02 15:04:05.999", ok: true},
+		{value: "yesterday", ok: false},
+		{value: "", ok: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.value, func(t *testing.T) {
+			t.Parallel()
+
+			actual, ok := detectTimeFormat(testCase.value)
+			if ok != testCase.ok || actual != testCase.expected {
+				t.Errorf("detectTimeFormat(%q) = %q, %v; want %q, %v", testCase.value, actual, ok, testCase.expected, testCase.ok)
+			}
+		})
+	}
+}
+
+type detectedKeys struct {
+	TimeKey         string
+	LevelKey        string
+	MessageKey      string
+	TimeInputFormat string
+	LevelScheme     string
+}
+
+//nolint:paralleltest // redirects os.Stderr
+func TestDetector(t *testing.T) {
+	oldStderr := os.Stderr
+
+	defer func() {
+		os.Stderr = oldStderr
+	}()
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	os.Stderr = devNull
+
+	testCases := []struct {
+		name     string
+		events   []string
+		explicit map[string]bool
+		expected detectedKeys
+	}{
+		{
+			name:     "zap",
+			events:   []string{`{"level":"info","ts":1756555555.123,"caller":"main.go:12","msg":"hi"}`},
+			expected: detectedKeys{TimeKey: "ts", LevelKey: "level", MessageKey: "msg", TimeInputFormat: "Unix"},
+		},
+		{
+			name:     "pino",
+			events:   []string{`{"level":30,"time":1756555555123,"pid":1,"hostname":"box","msg":"hi"}`},
+			expected: detectedKeys{TimeKey: "time", LevelKey: "level", MessageKey: "msg", TimeInputFormat: "UnixMilli", LevelScheme: "pino"},
+		},
+		{
+			name:     "OpenTelemetry",
+			events:   []string{`{"Timestamp":"1756555555123456789","SeverityText":"INFO","SeverityNumber":9,"Body":"hi"}`},
+			expected: detectedKeys{TimeKey: "Timestamp", LevelKey: "SeverityText", MessageKey: "Body", TimeInputFormat: "UnixNano"},
+		},
+		{
+			name: "Majority of events wins",
+			events: []string{
+				`{"time":"2025-08-24T21:51:45Z","msg":"only two keys"}`,
+				`{"time":"2025-08-24T21:51:45Z","level":"info","message":"zerolog"}`,
+				`{"time":"2025-08-24T21:51:45Z","level":"info","message":"zerolog"}`,
+			},
+			expected: detectedKeys{TimeKey: "time", LevelKey: "level", MessageKey: "message", TimeInputFormat: "RFC3339"},
+		},
+		{
+			name:     "Explicit keys win",
+			events:   []string{`{"@t":"2025-08-24T21:51:45Z","@l":"Warning","@mt":"Serilog","RenderedMessage":"x"}`},
+			explicit: map[string]bool{"message": true, "time-in": true},
+			expected: detectedKeys{TimeKey: "@t", LevelKey: "@l", MessageKey: "msg", TimeInputFormat: "RFC3339"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := newConfig()
+			d := newDetector(10, testCase.explicit)
+
+			for _, event := range testCase.events {
+				entry, err := parseObject(event)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				d.observe(entry, cfg)
+			}
+
+			actual := detectedKeys{
+				TimeKey:         cfg.TimeKey,
+				LevelKey:        cfg.LevelKey,
+				MessageKey:      cfg.MessageKey,
+				TimeInputFormat: cfg.TimeInputFormat,
+				LevelScheme:     cfg.LevelScheme,
+			}
+
+			if actual != testCase.expected {
+				t.Errorf("detected %+v; want %+v", actual, testCase.expected)
+			}
+		})
+	}
+}
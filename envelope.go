@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	envelopeNone   = "none"
+	envelopeDocker = "docker"
+	envelopeCRI    = "cri"
+	envelopeAuto   = "auto"
+
+	// streamKey is the property that holds the stream of unwrapped events.
+	streamKey = "stream"
+)
+
+var ErrUnknownEnvelope = errors.New("unknown envelope")
+
+// dockerLine is a line of the json-file log driver of Docker.
+type dockerLine struct {
+	Log    *string `json:"log"`
+	Stream string  `json:"stream"`
+}
+
+// envelope takes the lines of container logs out of their wrapping:
+//
+//   - docker: {"log":"{\"level\":\"info\"}\n","stream":"stdout","time":"..."}
+//   - cri:    2025-08-24T21:51:45.549Z stdout F {"level":"info"}
+//
+// Long lines are split into several partial ones by both formats. They are
+// joined again before they are passed on. Lines that aren't wrapped are passed
+// on as they are.
+type envelope struct {
+	mode string
+	// partial holds the start of split lines by stream.
+	partial map[string]*strings.Builder
+}
+
+func newEnvelope(mode string) *envelope {
+	return &envelope{mode: mode, partial: map[string]*strings.Builder{}}
+}
+
+// unwrap returns the content of a line and its stream. ok is false for parts of
+// a split line, which are kept until the line is complete.
+func (e *envelope) unwrap(line string) (content, stream string, ok bool) {
+	partial := false
+
+	switch {
+	case e.mode == envelopeDocker || e.mode == envelopeAuto && strings.HasPrefix(line, `{"log":`):
+		content, stream, partial, ok = unwrapDocker(line)
+	case e.mode == envelopeCRI || e.mode == envelopeAuto:
+		content, stream, partial, ok = unwrapCRI(line)
+	}
+
+	if !ok {
+		return line, "", true
+	}
+
+	builder, split := e.partial[stream]
+
+	if partial {
+		if !split {
+			builder = &strings.Builder{}
+			e.partial[stream] = builder
+		}
+
+		builder.WriteString(content)
+
+		return "", "", false
+	}
+
+	if split {
+		builder.WriteString(content)
+		content = builder.String()
+
+		delete(e.partial, stream)
+	}
+
+	return content, stream, true
+}
+
+// rest returns the split lines that never got completed.
+func (e *envelope) rest(handle func(content, stream string)) {
+	for _, stream := range slices.Sorted(maps.Keys(e.partial)) {
+		handle(e.partial[stream].String(), stream)
+	}
+
+	clear(e.partial)
+}
+
+// unwrapDocker reads a line of the json-file log driver. Lines without a line
+// break at the end are parts of a longer line.
+func unwrapDocker(line string) (content, stream string, partial, ok bool) {
+	var wrapped dockerLine
+
+	err := json.Unmarshal([]byte(line), &wrapped)
+	if err != nil || wrapped.Log == nil || wrapped.Stream == "" {
+		return "", "", false, false
+	}
+
+	content, complete := strings.CutSuffix(*wrapped.Log, "\n")
+
+	return content, wrapped.Stream, !complete, true
+}
+
+// unwrapCRI reads a line of the CRI log format of Kubernetes:
+// "<time> <stream> <tags> <content>", where the tag P marks a part of a longer
+// line and F the full or final part.
+func unwrapCRI(line string) (content, stream string, partial, ok bool) {
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) < 3 || (fields[1] != streamStdout && fields[1] != streamStderr) {
+		return "", "", false, false
+	}
+
+	_, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return "", "", false, false
+	}
+
+	tag, _, _ := strings.Cut(fields[2], ":")
+	if tag != "P" && tag != "F" {
+		return "", "", false, false
+	}
+
+	if len(fields) == 4 {
+		content = fields[3]
+	}
+
+	return content, fields[1], tag == "P", true
+}
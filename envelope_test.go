@@ -0,0 +1,110 @@
+package main
+
+import "testing"
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	type unwrapped struct {
+		content string
+		stream  string
+	}
+
+	testCases := []struct {
+		name     string
+		mode     string
+		lines    []string
+		expected []unwrapped
+	}{
+		{
+			name:     "Docker",
+			mode:     envelopeDocker,
+			lines:    []string{`{"log":"{\"msg\":\"hi\"}\n","stream":"stdout","time":"2025-08-24T21:51:45.549Z"}`},
+			expected: []unwrapped{{content: `{"msg":"hi"}`, stream: "stdout"}},
+		},
+		{
+			name: "Docker joins split lines by stream",
+			mode: envelopeDocker,
+			lines: []string{
+				`{"log":"{\"msg\":","stream":"stderr","time":"2025-08-24T21:51:45.549Z"}`,
+				`{"log":"out\n","stream":"stdout","time":"2025-08-24T21:51:45.549Z"}`,
+				`{"log":"\"err\"}\n","stream":"stderr","time":"2025-08-24T21:51:45.549Z"}`,
+			},
+			expected: []unwrapped{{content: "out", stream: "stdout"}, {content: `{"msg":"err"}`, stream: "stderr"}},
+		},
+		{
+			name: "CRI joins partial lines",
+			mode: envelopeCRI,
+			lines: []string{
+				`2025-08-24T21:51:45.549794202Z stdout P {"msg":`,
+				`2025-08-24T21:51:45.549794202Z stdout F "hi"}`,
+				`2025-08-24T21:51:45.549794202Z stderr F`,
+			},
+			expected: []unwrapped{{content: `{"msg":"hi"}`, stream: "stdout"}, {content: "", stream: "stderr"}},
+		},
+		{
+			name: "Auto",
+			mode: envelopeAuto,
+			lines: []string{
+				`{"log":"docker\n","stream":"stdout"}`,
+				`2025-08-24T21:51:45.549794202Z stderr F cri`,
+				`{"msg":"not wrapped"}`,
+				`yesterday stdout F not wrapped`,
+			},
+			expected: []unwrapped{
+				{content: "docker", stream: "stdout"},
+				{content: "cri", stream: "stderr"},
+				{content: `{"msg":"not wrapped"}`},
+				{content: "yesterday stdout F not wrapped"},
+			},
+		},
+		{
+			name:     "Lines of other formats pass",
+			mode:     envelopeCRI,
+			lines:    []string{`{"log":"docker\n","stream":"stdout"}`},
+			expected: []unwrapped{{content: `{"log":"docker\n","stream":"stdout"}`}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newEnvelope(testCase.mode)
+
+			var actual []unwrapped
+
+			for _, line := range testCase.lines {
+				content, stream, ok := e.unwrap(line)
+				if ok {
+					actual = append(actual, unwrapped{content: content, stream: stream})
+				}
+			}
+
+			if len(actual) != len(testCase.expected) {
+				t.Fatalf("got %q, expected %q", actual, testCase.expected)
+			}
+
+			for i := range actual {
+				if actual[i] != testCase.expected[i] {
+					t.Errorf("line %d is %q, expected %q", i, actual[i], testCase.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEnvelopeRest(t *testing.T) {
+	t.Parallel()
+
+	e := newEnvelope(envelopeCRI)
+	e.unwrap(`2025-08-24T21:51:45.549794202Z stdout P never `)
+
+	var rest []string
+
+	e.rest(func(content, stream string) { rest = append(rest, stream+": "+content) })
+
+	if len(rest) != 1 || rest[0] != "stdout: never " {
+		t.Errorf("expected the incomplete line at the end, got %q", rest)
+	}
+}
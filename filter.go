@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	policyShow = "show"
+	policyHide = "hide"
+)
+
+var (
+	ErrUnknownLevel  = errors.New("unknown level")
+	ErrUnknownPolicy = errors.New("unknown policy")
+)
+
+// levelFilter holds the parsed --min-level and --max-level thresholds.
+//
+// A zero value lets every event pass.
+type levelFilter struct {
+	min           int
+	max           int
+	active        bool
+	unknownPolicy string
+	table         levelTable
+}
+
+// newLevelFilter validates the level related options of a config.
+func newLevelFilter(cfg *Config) (levelFilter, error) {
+	filter := levelFilter{max: int(^uint(0) >> 1), unknownPolicy: cfg.UnknownLevelPolicy, table: cfg.levelTable}
+
+	if cfg.UnknownLevelPolicy != policyShow && cfg.UnknownLevelPolicy != policyHide {
+		return filter, fmt.Errorf("--unknown-level %q: %w", cfg.UnknownLevelPolicy, ErrUnknownPolicy)
+	}
+
+	if cfg.UnstructuredPolicy != policyShow && cfg.UnstructuredPolicy != policyHide {
+		return filter, fmt.Errorf("--unstructured %q: %w", cfg.UnstructuredPolicy, ErrUnknownPolicy)
+	}
+
+	if cfg.MinLevel != "" {
+		rank, ok := cfg.levelTable.severity(cfg.MinLevel)
+		if !ok {
+			return filter, fmt.Errorf("--min-level %q: %w", cfg.MinLevel, ErrUnknownLevel)
+		}
+
+		filter.min = rank
+		filter.active = true
+	}
+
+	if cfg.MaxLevel != "" {
+		rank, ok := cfg.levelTable.severity(cfg.MaxLevel)
+		if !ok {
+			return filter, fmt.Errorf("--max-level %q: %w", cfg.MaxLevel, ErrUnknownLevel)
+		}
+
+		filter.max = rank
+		filter.active = true
+	}
+
+	return filter, nil
+}
+
+// allows reports whether an event with the given level passes the filter.
+// Events with a missing or unknown level are handled by the unknown level
+// policy.
+func (f levelFilter) allows(level string) bool {
+	if !f.active {
+		return true
+	}
+
+	rank, ok := f.table.severity(level)
+	if !ok {
+		return f.unknownPolicy == policyShow
+	}
+
+	return rank >= f.min && rank <= f.max
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// followInterval is how often followed files are checked for new lines.
+const followInterval = 250 * time.Millisecond
+
+// ErrFollowNeedsFiles is returned for --follow without files to follow.
+var ErrFollowNeedsFiles = errors.New("--follow needs files, stdin can't be followed")
+
+// followReader reads a file like tail -F. At the end of the file it waits for
+// more lines instead of returning io.EOF.
+//
+// When the file is moved away and created again, e.g. by logrotate, the rest of
+// the old file is read before the new one. A truncated file is read from the
+// start again. A file that doesn't exist yet is waited for.
+type followReader struct {
+	ctx  context.Context //nolint:containedctx // Read can't take a context
+	name string
+	// file is nil until the file exists.
+	file     *os.File
+	next     *os.File
+	interval time.Duration
+}
+
+// openFollow opens a file to follow. Unless fromStart is set, only lines
+// written from now on are read. A file that doesn't exist yet is read from its
+// start once it is created.
+func openFollow(ctx context.Context, name string, fromStart bool, interval time.Duration) (*followReader, error) {
+	reader := &followReader{ctx: ctx, name: name, interval: interval}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return reader, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("can not follow %s: %w", name, err)
+	}
+
+	if !fromStart {
+		_, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, fmt.Errorf("can not follow %s: %w", name, err)
+		}
+	}
+
+	reader.file = file
+
+	return reader, nil
+}
+
+// Read returns io.EOF only when the context is done.
+func (r *followReader) Read(p []byte) (int, error) {
+	for r.file == nil {
+		file, err := os.Open(r.name)
+		if err == nil {
+			r.file = file
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return 0, fmt.Errorf("can not follow %s: %w", r.name, err)
+		} else if !r.wait() {
+			return 0, io.EOF
+		}
+	}
+
+	for {
+		n, err := r.file.Read(p)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, fmt.Errorf("can not follow %s: %w", r.name, err)
+		}
+
+		if n > 0 {
+			return n, nil
+		}
+
+		if r.next != nil {
+			// The old file is read to its end, carry on with the new one.
+			_ = r.file.Close()
+			r.file, r.next = r.next, nil
+
+			continue
+		}
+
+		changed, err := r.checkFile()
+		if err != nil {
+			return 0, err
+		}
+
+		if changed {
+			continue
+		}
+
+		if !r.wait() {
+			return 0, io.EOF
+		}
+	}
+}
+
+// wait sleeps for the interval. It returns false if the context is done.
+func (r *followReader) wait() bool {
+	select {
+	case <-r.ctx.Done():
+		return false
+	case <-time.After(r.interval):
+		return true
+	}
+}
+
+// checkFile looks for a new file at the path or truncation of the current
+// one. It reports whether there is something to read again.
+func (r *followReader) checkFile() (bool, error) {
+	current, err := r.file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("can not follow %s: %w", r.name, err)
+	}
+
+	info, err := os.Stat(r.name)
+	if err != nil {
+		// Moved away and not created again yet. Keep reading the old file,
+		// the application may still write to it.
+		return false, nil //nolint:nilerr // a missing file isn't a failure here
+	}
+
+	if !os.SameFile(current, info) {
+		next, err := os.Open(r.name)
+		if err != nil {
+			return false, nil //nolint:nilerr // try again next time
+		}
+
+		// Lines written to the old file since the last read come first.
+		r.next = next
+
+		return true, nil
+	}
+
+	offset, err := r.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, fmt.Errorf("can not follow %s: %w", r.name, err)
+	}
+
+	if info.Size() < offset {
+		_, err = r.file.Seek(0, io.SeekStart)
+		if err != nil {
+			return false, fmt.Errorf("can not follow %s: %w", r.name, err)
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *followReader) Close() error {
+	if r.next != nil {
+		_ = r.next.Close()
+	}
+
+	if r.file == nil {
+		return nil
+	}
+
+	return r.file.Close()
+}
+
+// follow renders the lines of all files as they are written until ctx is done.
+func follow(ctx context.Context, cfg *Config) error {
+	readers := make([]*followReader, 0, len(cfg.files))
+
+	defer func() {
+		for _, reader := range readers {
+			_ = reader.Close()
+		}
+	}()
+
+	for _, name := range cfg.files {
+		reader, err := openFollow(ctx, name, cfg.FromStart, followInterval)
+		if err != nil {
+			return err
+		}
+
+		readers = append(readers, reader)
+	}
+
+	lines := make(chan inputLine)
+	sources := sourceLabels(cfg.files, cfg)
+
+	var wg sync.WaitGroup
+
+	for i, reader := range readers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := readChunks(reader, sources[i], cfg, func(line inputLine) {
+				lines <- line
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error reading %s: %v\n", reader.name, err)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	for line := range lines {
+		render(line, cfg)
+	}
+
+	return nil
+}
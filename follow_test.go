@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFollowReader(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, os.O_CREATE|os.O_WRONLY, "before\n")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	reader, err := openFollow(ctx, path, false, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer reader.Close()
+
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+
+		_ = readLines(reader, 0, func(line string) { lines <- line })
+	}()
+
+	expect := func(expected string) {
+		t.Helper()
+
+		select {
+		case line := <-lines:
+			if line != expected {
+				t.Fatalf("read %q, expected %q", line, expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q was not read", expected)
+		}
+	}
+
+	writeFile(t, path, os.O_APPEND|os.O_WRONLY, "appended\n")
+	expect("appended")
+
+	// Rotated: lines written to the old file come before the new file.
+	err = os.Rename(path, path+".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, path+".1", os.O_APPEND|os.O_WRONLY, "late\n")
+	writeFile(t, path, os.O_CREATE|os.O_WRONLY, "rotated\n")
+	expect("late")
+	expect("rotated")
+
+	// Shorter than before, so the truncation is noticed whenever it is checked.
+	writeFile(t, path, os.O_TRUNC|os.O_WRONLY, "cut\n")
+	expect("cut")
+
+	cancel()
+
+	if _, ok := <-lines; ok {
+		t.Error("expected the reader to stop")
+	}
+}
+
+func TestFollowFromStart(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, path, os.O_CREATE|os.O_WRONLY, "first\n")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	reader, err := openFollow(ctx, path, true, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer reader.Close()
+
+	var lines []string
+
+	err = readLines(reader, 0, func(line string) { lines = append(lines, line) })
+	if err != nil || len(lines) != 1 || lines[0] != "first" {
+		t.Errorf("read %q, %v, expected the existing line", lines, err)
+	}
+}
+
+func TestFollowMissingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	reader, err := openFollow(ctx, path, false, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer reader.Close()
+
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+
+		_ = readLines(reader, 0, func(line string) { lines <- line })
+	}()
+
+	writeFile(t, path, os.O_CREATE|os.O_WRONLY, "created\n")
+
+	select {
+	case line := <-lines:
+		if line != "created" {
+			t.Fatalf("read %q, expected the first line of the new file", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the created file was not read")
+	}
+}
+
+func writeFile(t *testing.T, path string, flag int, text string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, flag, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	_, err = file.WriteString(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+const sgrReset = "\x1b[0m"
+
+// escapeSequence matches the escape sequences of colored text.
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// highlighter marks the matches of --highlight patterns in colored text.
+type highlighter struct {
+	patterns []*regexp.Regexp
+	// styles holds a style for every pattern.
+	styles []style
+}
+
+// newHighlighter compiles the patterns. Patterns get the highlight styles of
+// the theme in turn.
+func newHighlighter(patterns []string, theme *Theme) (*highlighter, error) {
+	h := &highlighter{}
+
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("--highlight %q: %w", pattern, err)
+		}
+
+		h.patterns = append(h.patterns, re)
+
+		if len(theme.Highlights) > 0 {
+			h.styles = append(h.styles, theme.Highlights[i%len(theme.Highlights)])
+		} else {
+			h.styles = append(h.styles, style{})
+		}
+	}
+
+	return h, nil
+}
+
+// apply highlights the matches in text.
+//
+// Patterns are matched against the text without its escape sequences. Escape
+// sequences inside a match are held back until the match ends, so the colors
+// of the text continue behind it. On overlaps the earlier pattern wins.
+func (h *highlighter) apply(text string) string {
+	if h == nil || len(h.patterns) == 0 || !pterm.PrintColor {
+		return text
+	}
+
+	sequences := escapeSequence.FindAllStringIndex(text, -1)
+
+	var plain strings.Builder
+
+	last := 0
+	for _, seq := range sequences {
+		plain.WriteString(text[last:seq[0]])
+		last = seq[1]
+	}
+
+	plain.WriteString(text[last:])
+
+	// marks holds the number of the pattern that matched a byte of the plain
+	// text, starting at 1.
+	marks := make([]int, plain.Len())
+	found := false
+
+	for i, re := range h.patterns {
+		for _, match := range re.FindAllStringIndex(plain.String(), -1) {
+			for j := match[0]; j < match[1]; j++ {
+				if marks[j] == 0 {
+					marks[j] = i + 1
+					found = true
+				}
+			}
+		}
+	}
+
+	if !found {
+		return text
+	}
+
+	var (
+		out     strings.Builder
+		state   []string // escape sequences since the last reset
+		current int
+		offset  int // position in the plain text
+	)
+
+	writePlain := func(segment string) {
+		for i := range len(segment) {
+			if mark := marks[offset+i]; mark != current {
+				if current != 0 {
+					out.WriteString(sgrReset + strings.Join(state, ""))
+				}
+
+				if mark != 0 {
+					out.WriteString(h.styles[mark-1].escape())
+				}
+
+				current = mark
+			}
+
+			out.WriteByte(segment[i])
+		}
+
+		offset += len(segment)
+	}
+
+	last = 0
+	for _, seq := range sequences {
+		writePlain(text[last:seq[0]])
+
+		escape := text[seq[0]:seq[1]]
+		if escape == sgrReset || escape == "\x1b[m" {
+			state = nil
+		} else if strings.HasSuffix(escape, "m") {
+			state = append(state, escape)
+		}
+
+		if current == 0 {
+			out.WriteString(escape)
+		}
+
+		last = seq[1]
+	}
+
+	writePlain(text[last:])
+
+	if current != 0 {
+		out.WriteString(sgrReset + strings.Join(state, ""))
+	}
+
+	return out.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pterm/pterm"
+)
+
+//nolint:paralleltest // highlighting depends on the global color setting of pterm
+func TestHighlight(t *testing.T) {
+	pterm.EnableColor()
+
+	theme, err := builtinTheme(defaultTheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := newHighlighter([]string{`req-\d+`, `id|req`}, theme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := theme.Highlights[0].escape()
+	second := theme.Highlights[1].escape()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "No match", input: "\x1b[32mhello\x1b[0m", expected: "\x1b[32mhello\x1b[0m"},
+		{name: "Plain text", input: "a req-1 b", expected: "a " + first + "req-1\x1b[0m b"},
+		{
+			name:     "Colors continue after a match",
+			input:    "\x1b[32m\"req-42\"\x1b[0m",
+			expected: "\x1b[32m\"" + first + "req-42\x1b[0m\x1b[32m\"\x1b[0m",
+		},
+		{
+			name:     "Escape sequences inside a match are held back",
+			input:    "\x1b[1m{\x1b[0m\x1b[32mre\x1b[0m\x1b[33mq-7\x1b[0m",
+			expected: "\x1b[1m{\x1b[0m\x1b[32m" + first + "req-7\x1b[0m",
+		},
+		{name: "Patterns get different colors", input: "id req-1", expected: second + "id\x1b[0m " + first + "req-1\x1b[0m"},
+		{name: "Earlier patterns win", input: "req", expected: second + "req\x1b[0m"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := h.apply(testCase.input)
+			if actual != testCase.expected {
+				t.Errorf("apply(%q) = %q; want %q", testCase.input, actual, testCase.expected)
+			}
+		})
+	}
+
+	pterm.DisableColor()
+
+	if actual := h.apply("req-1"); actual != "req-1" {
+		t.Errorf("apply without colors = %q; want the text unchanged", actual)
+	}
+
+	_, err = newHighlighter([]string{`(`}, theme)
+	if err == nil {
+		t.Error("expected an error for a bad pattern")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// stdinName is the file argument that stands for stdin.
+const stdinName = "-"
+
+// ErrNoZstd is returned for zstd compressed files if the zstd command is
+// missing. The standard library has no zstd decoder.
+var ErrNoZstd = errors.New("reading zstd compressed files needs the zstd command")
+
+// Magic numbers of the compression formats axt reads.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// inputFile is an opened file argument.
+type inputFile struct {
+	io.Reader
+	// closers are closed in reverse order.
+	closers []io.Closer
+}
+
+func (f *inputFile) Close() error {
+	var errs []error
+
+	for i := len(f.closers) - 1; i >= 0; i-- {
+		errs = append(errs, f.closers[i].Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// openInput opens a file argument. Files compressed with gzip, bzip2 or zstd
+// are decompressed, no matter their name. stdin is read as it is, so axt
+// doesn't wait for more than a line of a live stream.
+func openInput(name string) (*inputFile, error) {
+	if name == stdinName {
+		return &inputFile{Reader: os.Stdin}, nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("can not open %s: %w", name, err)
+	}
+
+	input := &inputFile{closers: []io.Closer{file}}
+	buffered := bufio.NewReader(file)
+
+	// Short files can't be compressed, io.EOF is fine here.
+	magic, err := buffered.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = input.Close()
+
+		return nil, fmt.Errorf("can not read %s: %w", name, err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			_ = input.Close()
+
+			return nil, fmt.Errorf("can not decompress %s: %w", name, err)
+		}
+
+		input.Reader = gz
+		input.closers = append(input.closers, gz)
+	case bytes.HasPrefix(magic, bzip2Magic):
+		input.Reader = bzip2.NewReader(buffered)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstd, err := decompressZstd(buffered)
+		if err != nil {
+			_ = input.Close()
+
+			return nil, fmt.Errorf("can not decompress %s: %w", name, err)
+		}
+
+		input.Reader = zstd
+		input.closers = append(input.closers, zstd)
+	default:
+		input.Reader = buffered
+	}
+
+	return input, nil
+}
+
+// commandOutput reads the output of a command. Closing it waits for the
+// command, so failures of the command are reported.
+type commandOutput struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+func (c *commandOutput) Close() error {
+	err := c.cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.cmd.Path, err)
+	}
+
+	return nil
+}
+
+// decompressZstd decompresses r with the zstd command.
+func decompressZstd(r io.Reader) (*commandOutput, error) {
+	cmd := exec.Command("zstd", "--decompress", "--stdout", "--quiet")
+	cmd.Stdin = r
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("can not read zstd: %w", err)
+	}
+
+	err = cmd.Start()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, ErrNoZstd
+	} else if err != nil {
+		return nil, fmt.Errorf("can not start zstd: %w", err)
+	}
+
+	return &commandOutput{Reader: out, cmd: cmd}, nil
+}
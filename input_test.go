@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const compressedLine = `{"level":"error","msg":"three"}` + "\n"
+
+func TestOpenInput(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+
+	writer := gzip.NewWriter(&gz)
+	_, _ = writer.Write([]byte(compressedLine))
+	_ = writer.Close()
+
+	files := map[string][]byte{
+		"plain.log": []byte(compressedLine),
+		"short.log": []byte("{}"),
+		// gzip compressed, no matter the name
+		"gzip.log": gz.Bytes(),
+		// printf '{"level":"error","msg":"three"}\n' | bzip2
+		"bzip2.bz2": []byte("\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xe6\x7e\x39\x28\x00\x00\x0f\x59\x80\x00\x10\x10" +
+			"\x04\x00\x10\x02\xc6\x9d\x0a\x20\x00\x31\x4c\x00\x13\x42\x8f\x53\x4c\xd2\x0d\xa8\xb9\x26\x11\x13\x37\xc4" +
+			"\x2c\x9a\x4e\xd6\x75\x41\x96\x82\x1f\x17\x72\x45\x38\x50\x90\xe6\x7e\x39\x28"),
+	}
+
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), content, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "plain.log", expected: compressedLine},
+		{name: "short.log", expected: "{}"},
+		{name: "gzip.log", expected: compressedLine},
+		{name: "bzip2.bz2", expected: compressedLine},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			input, err := openInput(filepath.Join(dir, testCase.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := io.ReadAll(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = input.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("read %q, expected %q", actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestOpenZstd(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "events.zst")
+
+	cmd := exec.Command("zstd", "--quiet", "-o", path)
+	cmd.Stdin = bytes.NewBufferString(compressedLine)
+
+	err = cmd.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := openInput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := io.ReadAll(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = input.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(actual) != compressedLine {
+		t.Errorf("read %q, expected %q", actual, compressedLine)
+	}
+}
+
+func TestOpenMissingFile(t *testing.T) {
+	t.Parallel()
+
+	_, err := openInput(filepath.Join(t.TempDir(), "missing.log"))
+	if err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
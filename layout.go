@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/pretty"
+)
+
+const (
+	layoutExpanded = "expanded"
+	layoutCompact  = "compact"
+)
+
+var ErrUnknownLayout = errors.New("unknown layout")
+
+// formatCompactProperties returns the properties of an entry as key=value
+// pairs for the end of the headline, which is used columns wide.
+//
+// Strings are quoted if they contain spaces, quotes, equal signs or control
+// characters, everything else is written as compact JSON. The line is cut off
+// at cfg.lineWidth.
+func formatCompactProperties(entry *object, keys []string, used int, cfg *Config) string {
+	var line strings.Builder
+
+	remaining := cfg.lineWidth - used
+
+	for _, key := range keys {
+		value, _ := entry.Get(key)
+		width := 0
+
+		if cfg.lineWidth > 0 {
+			// Leave room for the space and the key with its equal sign.
+			width = remaining - utf8.RuneCountInString(key) - 2
+			if width < 1 {
+				if remaining >= 2 {
+					line.WriteString(" …")
+				}
+
+				break
+			}
+		}
+
+		formattedValue := formatCompactValue(value, width, cfg)
+		remaining -= utf8.RuneCountInString(key) + 2 + visibleWidth(formattedValue)
+
+		formattedKey := cfg.highlight.apply(cfg.theme.Key.Sprint(key))
+		fmt.Fprintf(&line, " %s=%s", formattedKey, cfg.highlight.apply(formattedValue))
+	}
+
+	return line.String()
+}
+
+// formatCompactValue formats a single value on one line. A width of zero means
+// no limit.
+func formatCompactValue(value any, width int, cfg *Config) string {
+	if str, ok := value.(string); ok {
+		if needsQuotes(str) {
+			str = strconv.Quote(str)
+		}
+
+		text, cut := truncate(str, width)
+
+		return cfg.theme.JSON.String.Sprint(text) + cut
+	}
+
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return cfg.theme.Error.Sprint(fmt.Sprintf("%v", value))
+	}
+
+	if cfg.SortKeys {
+		jsonBytes = pretty.Ugly(pretty.PrettyOptions(jsonBytes, &pretty.Options{SortKeys: true}))
+	}
+
+	text, cut := truncate(string(jsonBytes), width)
+
+	return string(pretty.Color([]byte(text), jsonColor(cfg.theme))) + cut
+}
+
+// needsQuotes reports whether a string value would be ambiguous without quotes.
+func needsQuotes(str string) bool {
+	if str == "" {
+		return true
+	}
+
+	return strings.ContainsFunc(str, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError
+	})
+}
+
+// visibleWidth returns the number of runes of text without its escape
+// sequences.
+func visibleWidth(text string) int {
+	return utf8.RuneCountInString(escapeSequence.ReplaceAllString(text, ""))
+}
+
+// truncate cuts text to width runes. The second return value is an ellipsis
+// if the text was cut and empty otherwise.
+func truncate(text string, width int) (string, string) {
+	if width <= 0 || utf8.RuneCountInString(text) <= width {
+		return text, ""
+	}
+
+	runes := []rune(text)
+
+	return string(runes[:width-1]), "…"
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormatCompactValue(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig()
+
+	nested := newObject()
+	nested.Set("b", 1.0)
+	nested.Set("a", []any{"x", nil})
+
+	testCases := []struct {
+		name     string
+		value    any
+		width    int
+		expected string
+	}{
+		{name: "Plain string", value: "GET", expected: "GET"},
+		{name: "String with spaces", value: "a b", expected: `"a b"`},
+		{name: "Multi-line string", value: "line 1\nline 2", expected: `"line 1\nline 2"`},
+		{name: "Empty string", value: "", expected: `""`},
+		{name: "Number", value: 12.5, expected: "12.5"},
+		{name: "Object keeps its order", value: nested, expected: `{"b":1,"a":["x",null]}`},
+		{name: "Long string", value: "abcdefghijklmnop", width: 10, expected: "abcdefghi…"},
+		{name: "Long object", value: nested, width: 10, expected: `{"b":1,"a…`},
+		{name: "Short enough", value: "abcdefghij", width: 10, expected: "abcdefghij"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := stripAnsi(formatCompactValue(testCase.value, testCase.width, cfg))
+			if actual != testCase.expected {
+				t.Errorf("formatCompactValue(%v, %d) = %q; want %q", testCase.value, testCase.width, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestFormatCompactProperties(t *testing.T) {
+	t.Parallel()
+
+	entry := newObject()
+	entry.Set("method", "GET")
+	entry.Set("path", "/api/users/12345/orders")
+	entry.Set("status", 200.0)
+
+	testCases := []struct {
+		name     string
+		width    int
+		used     int
+		expected string
+	}{
+		{name: "No limit", expected: " method=GET path=/api/users/12345/orders status=200"},
+		{name: "Everything fits", width: 61, used: 10, expected: " method=GET path=/api/users/12345/orders status=200"},
+		{name: "Cut in a value", width: 40, used: 10, expected: " method=GET path=/api/users/1…"},
+		{name: "No room for the next key", width: 53, used: 10, expected: " method=GET path=/api/users/12345/orders …"},
+		{name: "Headline fills the line", width: 40, used: 40, expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := newConfig()
+			cfg.lineWidth = testCase.width
+
+			actual := stripAnsi(formatCompactProperties(entry, entry.Keys(), testCase.used, cfg))
+			if actual != testCase.expected {
+				t.Errorf("formatCompactProperties() = %q; want %q", actual, testCase.expected)
+			}
+
+			if testCase.width > 0 && testCase.used+utf8.RuneCountInString(actual) > testCase.width {
+				t.Errorf("formatCompactProperties() = %q is wider than %d", actual, testCase.width-testCase.used)
+			}
+		})
+	}
+}
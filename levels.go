@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pterm/pterm"
+)
+
+var (
+	ErrUnknownLevelScheme = errors.New("unknown level scheme")
+	ErrBadLevelDefinition = errors.New("bad level definition")
+)
+
+// levelFields are the properties of a level definition.
+var levelFields = []string{"aliases", "bg", "color", "emoji", "fg", "rank", "text"}
+
+// levelDefinition adds a level or changes a level of levelMap.
+//
+// Fields hold the properties as given by the user, aliases separated by
+// commas. Only fields that are set are changed.
+type levelDefinition struct {
+	name   string
+	origin string
+	fields map[string]string
+}
+
+// parseLevelDefinition checks the fields of a level definition.
+func parseLevelDefinition(name, origin string, fields map[string]string) (levelDefinition, error) {
+	def := levelDefinition{name: strings.ToUpper(strings.TrimSpace(name)), origin: origin, fields: fields}
+
+	if def.name == "" {
+		return def, fmt.Errorf("%s: %w: missing name", origin, ErrBadLevelDefinition)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		value := fields[key]
+
+		var err error
+
+		switch key {
+		case "rank":
+			_, err = strconv.Atoi(value)
+		case "bg", "fg", "color":
+			_, err = parsePaint(value)
+		case "aliases", "emoji", "text":
+		default:
+			return def, fmt.Errorf("%s: level %s: %w: unknown property %q, expected one of %s",
+				origin, def.name, ErrBadLevelDefinition, key, strings.Join(levelFields, ", "))
+		}
+
+		if err != nil {
+			return def, fmt.Errorf("%s: level %s: %w: %w", origin, def.name, ErrBadLevelDefinition, err)
+		}
+	}
+
+	return def, nil
+}
+
+// parseLevelFlag parses a level definition given as logfmt, e.g.
+// "name=NOTICE rank=35 bg=cyan".
+func parseLevelFlag(spec string) (levelDefinition, error) {
+	origin := fmt.Sprintf("--define-level %q", spec)
+
+	entry, err := parseLogfmt(spec, false)
+	if err != nil {
+		return levelDefinition{}, fmt.Errorf("%s: %w", origin, err)
+	}
+
+	fields := map[string]string{}
+
+	for _, key := range entry.Keys() {
+		value, _ := entry.Get(key)
+		fields[key], _ = value.(string)
+	}
+
+	name := fields["name"]
+	delete(fields, "name")
+
+	return parseLevelDefinition(name, origin, fields)
+}
+
+// parseLevelTables parses the [levels.NAME] tables of a config file.
+func parseLevelTables(value any, origin string) ([]levelDefinition, error) {
+	tables, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s: %w: expected tables", origin, levelsKey, ErrBadLevelDefinition)
+	}
+
+	defs := make([]levelDefinition, 0, len(tables))
+
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		tableOrigin := fmt.Sprintf("%s [%s.%s]", origin, levelsKey, name)
+
+		table, ok := tables[name].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: expected a table", tableOrigin, ErrBadLevelDefinition)
+		}
+
+		fields := map[string]string{}
+
+		for key, v := range table {
+			values, err := configStrings(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", tableOrigin, key, err)
+			}
+
+			if _, isList := v.([]any); isList != (key == "aliases") {
+				return nil, fmt.Errorf("%s: %s: %w: only aliases is a list", tableOrigin, key, ErrBadLevelDefinition)
+			}
+
+			fields[key] = strings.Join(values, ",")
+		}
+
+		def, err := parseLevelDefinition(name, tableOrigin, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
+
+// levelTable holds the levels of a config: levelMap merged with the level
+// definitions of the user.
+type levelTable struct {
+	levels map[string]levelInfo
+	// width is the width of the widest level text. Shorter levels are padded
+	// to it.
+	width int
+}
+
+// newLevelTable applies level definitions in order over levelMap.
+//
+// A definition of a known level only changes the fields it sets, new levels
+// must have a rank. Aliases get a copy of the level.
+func newLevelTable(defs []levelDefinition) (levelTable, error) {
+	table := levelTable{levels: maps.Clone(levelMap)}
+
+	for _, def := range defs {
+		info, ok := table.levels[def.name]
+		if !ok {
+			if _, hasRank := def.fields["rank"]; !hasRank {
+				return table, fmt.Errorf("%s: level %s: %w: new levels need a rank", def.origin, def.name, ErrBadLevelDefinition)
+			}
+
+			info = levelInfo{
+				Background: ansi(pterm.FgWhite),
+				Foreground: ansi(pterm.FgBlack),
+				MainColor:  ansi(pterm.FgDefault),
+				Text:       " " + def.name + " ",
+			}
+		}
+
+		// The fields have been checked by parseLevelDefinition.
+		for key, value := range def.fields {
+			switch key {
+			case "rank":
+				info.Severity, _ = strconv.Atoi(value)
+			case "bg":
+				info.Background, _ = parsePaint(value)
+			case "fg":
+				info.Foreground, _ = parsePaint(value)
+			case "color":
+				info.MainColor, _ = parsePaint(value)
+			case "emoji":
+				info.Emoji = value
+			case "text":
+				info.Text = value
+			}
+		}
+
+		table.levels[def.name] = info
+
+		for _, alias := range def.aliases() {
+			table.levels[alias] = info
+		}
+	}
+
+	for _, info := range table.levels {
+		table.width = max(table.width, utf8.RuneCountInString(info.Text))
+	}
+
+	return table, nil
+}
+
+// aliases returns the uppercase aliases of a definition.
+func (d levelDefinition) aliases() []string {
+	var aliases []string
+
+	for alias := range strings.SplitSeq(d.fields["aliases"], ",") {
+		alias = strings.ToUpper(strings.TrimSpace(alias))
+		if alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+// get returns the properties of a level, regardless of its case.
+func (t levelTable) get(level string) (levelInfo, bool) {
+	info, ok := t.levels[strings.ToUpper(level)]
+
+	return info, ok
+}
+
+// severity returns the rank of a log level.
+//
+// The second return value is false if the level is not known.
+func (t levelTable) severity(level string) (int, bool) {
+	info, ok := t.get(level)
+	if !ok {
+		return 0, false
+	}
+
+	return info.Severity, true
+}
+
+// levelRange maps the numeric levels from min to max to a name in levelMap.
+type levelRange struct {
+	min  float64
+	max  float64
+	name string
+}
+
+// levelSchemes maps numeric levels of logging libraries and protocols onto the
+// names in levelMap. Numbers outside of a scheme are unknown levels.
+var levelSchemes = map[string][]levelRange{
+	"pino": {
+		{min: 10, max: 10, name: "TRACE"},
+		{min: 20, max: 20, name: "DEBUG"},
+		{min: 30, max: 30, name: "INFO"},
+		{min: 40, max: 40, name: "WARN"},
+		{min: 50, max: 50, name: "ERROR"},
+		{min: 60, max: 60, name: "FATAL"},
+	},
+	"bunyan": {
+		{min: 10, max: 10, name: "TRACE"},
+		{min: 20, max: 20, name: "DEBUG"},
+		{min: 30, max: 30, name: "INFO"},
+		{min: 40, max: 40, name: "WARN"},
+		{min: 50, max: 50, name: "ERROR"},
+		{min: 60, max: 60, name: "FATAL"},
+	},
+	// syslog severities are inverted: the lower, the more severe.
+	"syslog": {
+		{min: 0, max: 0, name: "FATAL"},
+		{min: 1, max: 2, name: "CRITICAL"},
+		{min: 3, max: 3, name: "ERR"},
+		{min: 4, max: 4, name: "WARNING"},
+		{min: 5, max: 6, name: "INFO"},
+		{min: 7, max: 7, name: "DEBUG"},
+	},
+	// OpenTelemetry SeverityNumber
+	"otel": {
+		{min: 1, max: 4, name: "TRACE"},
+		{min: 5, max: 8, name: "DEBUG"},
+		{min: 9, max: 12, name: "INFO"},
+		{min: 13, max: 16, name: "WARN"},
+		{min: 17, max: 20, name: "ERROR"},
+		{min: 21, max: 24, name: "FATAL"},
+	},
+	"zap": {
+		{min: -1, max: -1, name: "DEBUG"},
+		{min: 0, max: 0, name: "INFO"},
+		{min: 1, max: 1, name: "WARN"},
+		{min: 2, max: 2, name: "ERROR"},
+		{min: 3, max: 4, name: "CRITICAL"},
+		{min: 5, max: 5, name: "FATAL"},
+	},
+}
+
+// numericLevel returns the level name of a number according to scheme. Unknown
+// numbers are returned as they are.
+func numericLevel(number float64, scheme string) string {
+	for _, r := range levelSchemes[scheme] {
+		if number >= r.min && number <= r.max {
+			return r.name
+		}
+	}
+
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// levelName turns the raw value of a level property into a level name.
+// Numbers, also when given as strings like in logfmt, are looked up in scheme.
+func levelName(value any, scheme string) string {
+	switch v := value.(type) {
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return v
+		}
+
+		return numericLevel(number, scheme)
+	case float64:
+		return numericLevel(v, scheme)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLevelName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		value    any
+		scheme   string
+		expected string
+	}{
+		{name: "Names pass through", value: "warn", scheme: "pino", expected: "warn"},
+		{name: "pino info", value: 30.0, scheme: "pino", expected: "INFO"},
+		{name: "bunyan fatal", value: 60.0, scheme: "bunyan", expected: "FATAL"},
+		{name: "pino unknown number", value: 35.0, scheme: "pino", expected: "35"},
+		{name: "syslog emergency", value: 0.0, scheme: "syslog", expected: "FATAL"},
+		{name: "syslog warning", value: 4.0, scheme: "syslog", expected: "WARNING"},
+		{name: "syslog debug", value: 7.0, scheme: "syslog", expected: "DEBUG"},
+		{name: "otel range", value: 11.0, scheme: "otel", expected: "INFO"},
+		{name: "otel out of range", value: 25.0, scheme: "otel", expected: "25"},
+		{name: "zap debug", value: -1.0, scheme: "zap", expected: "DEBUG"},
+		{name: "zap dpanic", value: 3.0, scheme: "zap", expected: "CRITICAL"},
+		{name: "Numeric string from logfmt", value: "50", scheme: "pino", expected: "ERROR"},
+		{name: "Not a level", value: true, scheme: "pino", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := levelName(testCase.value, testCase.scheme)
+			if actual != testCase.expected {
+				t.Errorf("levelName(%v, %q) = %q; want %q", testCase.value, testCase.scheme, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestLevelTable(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		specs    []string
+		level    string
+		severity int
+		text     string
+		width    int
+		err      bool
+	}{
+		{name: "Built-in levels", level: "warning", severity: 40, text: "WARNING", width: 8},
+		{
+			name:     "New level",
+			specs:    []string{"name=notice rank=35 bg=cyan"},
+			level:    "NOTICE",
+			severity: 35,
+			text:     " NOTICE ",
+			width:    8,
+		},
+		{
+			name:     "Aliases share the level",
+			specs:    []string{"name=SUCCESS rank=30 aliases=OK,done"},
+			level:    "DONE",
+			severity: 30,
+			text:     " SUCCESS ",
+			width:    9,
+		},
+		{
+			name:     "Built-in levels are merged",
+			specs:    []string{"name=INFO text=INFO", "name=INFO rank=25"},
+			level:    "INFO",
+			severity: 25,
+			text:     "INFO",
+			width:    8,
+		},
+		{name: "New level without rank", specs: []string{"name=AUDIT"}, err: true},
+		{name: "Unknown color", specs: []string{"name=AUDIT rank=1 fg=mauve"}, err: true},
+		{name: "Unknown property", specs: []string{"name=AUDIT rank=1 sound=beep"}, err: true},
+		{name: "Missing name", specs: []string{"rank=1"}, err: true},
+	}
+
+	// Runs after the parallel subtests are done.
+	t.Cleanup(func() {
+		if _, ok := levelMap["NOTICE"]; ok {
+			t.Error("level definitions must not change levelMap")
+		}
+	})
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				defs []levelDefinition
+				err  error
+			)
+
+			for _, spec := range testCase.specs {
+				var def levelDefinition
+
+				def, err = parseLevelFlag(spec)
+				if err != nil {
+					break
+				}
+
+				defs = append(defs, def)
+			}
+
+			var table levelTable
+			if err == nil {
+				table, err = newLevelTable(defs)
+			}
+
+			if testCase.err {
+				if !errors.Is(err, ErrBadLevelDefinition) {
+					t.Errorf("expected a bad level definition for %q, got %v", testCase.specs, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			severity, ok := table.severity(testCase.level)
+			info, _ := table.get(testCase.level)
+
+			if !ok || severity != testCase.severity || info.Text != testCase.text || table.width != testCase.width {
+				t.Errorf("%s: got severity %d, text %q, width %d; want %d, %q, %d",
+					testCase.level, severity, info.Text, table.width, testCase.severity, testCase.text, testCase.width)
+			}
+		})
+	}
+}
@@ -3,15 +3,13 @@ package main
 import (
 	"fmt"
 	"strings"
-
-	"github.com/pterm/pterm"
 )
 
 // addBorder shows a pretty border around logged properties.
-func addBorder(logLines []string, verticalLine string) {
+func addBorder(logLines []string, verticalLine string, theme *Theme) {
 	if len(logLines) > 3 {
-		outerColor := pterm.NewRGB(70, 70, 70)
-		innerColor := pterm.NewRGB(150, 150, 150)
+		outerColor := theme.BorderOuter.toRGB()
+		innerColor := theme.BorderInner.toRGB()
 
 		// Fade for the line
 		for index, line := range logLines {
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	inputAuto   = "auto"
+	inputJSON   = "json"
+	inputLogfmt = "logfmt"
+)
+
+var (
+	ErrUnknownInput = errors.New("unknown input format")
+	ErrBadLogfmt    = errors.New("not logfmt")
+)
+
+// decodeLine turns a line of input into an entry according to --input.
+//
+// In "auto" mode lines starting with "{" are decoded as JSON, everything else
+// as strict logfmt.
+func decodeLine(line string, cfg *Config) (*object, error) {
+	switch cfg.InputFormat {
+	case inputJSON:
+		return parseObject(line)
+	case inputLogfmt:
+		return parseLogfmt(line, true)
+	default:
+		if strings.HasPrefix(strings.TrimSpace(line), "{") {
+			return parseObject(line)
+		}
+
+		return parseLogfmt(line, false)
+	}
+}
+
+// parseLogfmt decodes a single logfmt line like `level=info msg="hello world"`.
+//
+// All values are strings, since logfmt has no types. A key without a value
+// (e.g. `debug`) is stored as true if allowBare is set and is an error
+// otherwise; strict parsing keeps plain text from being mistaken for logfmt.
+func parseLogfmt(line string, allowBare bool) (*object, error) {
+	if strings.Contains(line, "\n") {
+		return nil, fmt.Errorf("%w: more than one line", ErrBadLogfmt)
+	}
+
+	entry := newObject()
+	rest := strings.TrimSpace(line)
+
+	for rest != "" {
+		end := strings.IndexAny(rest, "= ")
+		if end == -1 {
+			end = len(rest)
+		}
+
+		key := rest[:end]
+		if key == "" || strings.ContainsRune(key, '"') {
+			return nil, fmt.Errorf("%w: bad key at %q", ErrBadLogfmt, rest)
+		}
+
+		rest = rest[end:]
+
+		if !strings.HasPrefix(rest, "=") {
+			if !allowBare {
+				return nil, fmt.Errorf("%w: key %q has no value", ErrBadLogfmt, key)
+			}
+
+			entry.Set(key, true)
+			rest = strings.TrimLeft(rest, " ")
+
+			continue
+		}
+
+		value, remaining, err := readLogfmtValue(rest[1:])
+		if err != nil {
+			return nil, fmt.Errorf("value of %q: %w", key, err)
+		}
+
+		entry.Set(key, value)
+		rest = strings.TrimLeft(remaining, " ")
+	}
+
+	if entry.Len() == 0 {
+		return nil, fmt.Errorf("%w: empty line", ErrBadLogfmt)
+	}
+
+	return entry, nil
+}
+
+// readLogfmtValue reads a quoted or bare value from the start of s and returns
+// it together with the unread remainder.
+func readLogfmtValue(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexByte(s, ' ')
+		if end == -1 {
+			end = len(s)
+		}
+
+		if strings.ContainsAny(s[:end], `="`) {
+			return "", "", fmt.Errorf("%w: unexpected character in %q", ErrBadLogfmt, s[:end])
+		}
+
+		return s[:end], s[end:], nil
+	}
+
+	escaped := false
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("%w: can not unquote: %w", ErrBadLogfmt, err)
+			}
+
+			if i+1 < len(s) && s[i+1] != ' ' {
+				return "", "", fmt.Errorf("%w: missing space after %s", ErrBadLogfmt, s[:i+1])
+			}
+
+			return value, s[i+1:], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: unterminated quote in %q", ErrBadLogfmt, s)
+}
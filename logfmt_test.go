@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		input     string
+		allowBare bool
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:     "Simple pairs",
+			input:    `time=2025-08-24T21:51:45.549Z level=info msg=started`,
+			expected: `{"time":"2025-08-24T21:51:45.549Z","level":"info","msg":"started"}`,
+		},
+		{
+			name:     "Quoted values with escapes",
+			input:    `msg="hello \"world\"" path="/a b" empty=`,
+			expected: `{"msg":"hello \"world\"","path":"/a b","empty":""}`,
+		},
+		{
+			name:     "Extra spaces between pairs",
+			input:    `  a=1    b=2  `,
+			expected: `{"a":"1","b":"2"}`,
+		},
+		{
+			name:      "Bare key allowed",
+			input:     `level=debug verbose`,
+			allowBare: true,
+			expected:  `{"level":"debug","verbose":true}`,
+		},
+		{
+			name:    "Bare key rejected in strict mode",
+			input:   `something without proper JSON`,
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated quote",
+			input:   `msg="oops`,
+			wantErr: true,
+		},
+		{
+			name:    "Missing key",
+			input:   `=value`,
+			wantErr: true,
+		},
+		{
+			name:    "Garbage after quoted value",
+			input:   `msg="a"b`,
+			wantErr: true,
+		},
+		{
+			name:      "Empty line",
+			input:     `   `,
+			allowBare: true,
+			wantErr:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			entry, err := parseLogfmt(testCase.input, testCase.allowBare)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("parseLogfmt() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if testCase.wantErr {
+				if !errors.Is(err, ErrBadLogfmt) {
+					t.Errorf("parseLogfmt() error = %v; want it to wrap ErrBadLogfmt", err)
+				}
+
+				return
+			}
+
+			actual, err := json.Marshal(entry)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("parseLogfmt(%q) = %s; want %s", testCase.input, actual, testCase.expected)
+			}
+		})
+	}
+}
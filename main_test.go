@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -13,5 +14,38 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
-	os.Exit(m.Run())
+	// Don't let the config of the developer running the tests interfere.
+	configHome, err := os.MkdirTemp("", "axt-test-config")
+	if err != nil {
+		fmt.Println("Error: can not create temporary config directory:", err)
+		os.Exit(1)
+	}
+
+	os.Setenv("XDG_CONFIG_HOME", configHome)
+
+	// Neither may a project config above the working directory. The search
+	// stops at the first one it finds, so an empty one keeps it from going
+	// up further. Temporary directories of tests are below it as well.
+	workDir := filepath.Join(configHome, "work")
+
+	err = os.Mkdir(workDir, 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(workDir, projectConfigFile), nil, 0o600)
+	}
+
+	if err == nil {
+		err = os.Chdir(workDir)
+	}
+
+	if err != nil {
+		fmt.Println("Error: can not create temporary working directory:", err)
+		os.Exit(1)
+	}
+
+	os.Setenv("TMPDIR", workDir)
+
+	code := m.Run()
+
+	os.RemoveAll(configHome)
+	os.Exit(code)
 }
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMergeNeedsFiles = errors.New("--merge needs files to merge")
+	ErrMergeFollow     = errors.New("--merge can't be combined with --follow")
+)
+
+// fileOptions are the options a file of --merge can have of its own, e.g.
+// api.log:time=ts,time-in=Unix.
+var fileOptions = map[string]func(cfg *Config, value string){
+	"time":         func(cfg *Config, value string) { cfg.TimeKey = value },
+	"level":        func(cfg *Config, value string) { cfg.LevelKey = value },
+	"message":      func(cfg *Config, value string) { cfg.MessageKey = value },
+	"time-in":      func(cfg *Config, value string) { cfg.TimeInputFormat = value },
+	"level-scheme": func(cfg *Config, value string) { cfg.LevelScheme = value },
+	"input":        func(cfg *Config, value string) { cfg.InputFormat = value },
+	"envelope":     func(cfg *Config, value string) { cfg.Envelope = value },
+}
+
+// mergeSource is a file of --merge.
+//
+// Every source has its own copy of the config with the options of the file.
+// With --detect every source has its own detector as well, so the keys and
+// time format of each file are guessed on their own.
+type mergeSource struct {
+	name   string
+	label  string
+	cfg    *Config
+	chunks chan inputLine
+	// err is set before chunks is closed.
+	err error
+	// events are the next events of the source, they all have the same time.
+	events []*mergeEvent
+	// last is the time of the last event with a time.
+	last time.Time
+	done bool
+}
+
+// mergeEvent is a chunk of input with the time it is ordered by.
+type mergeEvent struct {
+	line inputLine
+	// entry is nil for unstructured text.
+	entry *object
+	time  time.Time
+}
+
+// merge renders the events of all files ordered by their time.
+//
+// Events without a time that can be parsed, like stack traces, get the time of
+// the event before them in the same file, so they stay with it. At the start of
+// a file they get the time of the first event with one. Events with the
+// same time keep the order of the files, but an event stays behind the event
+// of the same file that was printed last.
+func merge(cfg *Config) {
+	names := make([]string, len(cfg.files))
+	configs := make([]*Config, len(cfg.files))
+
+	for i, arg := range cfg.files {
+		var err error
+
+		names[i], configs[i], err = fileConfig(arg, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	labels := sourceLabels(names, cfg)
+	sources := make([]*mergeSource, len(cfg.files))
+
+	for i, name := range names {
+		sources[i] = &mergeSource{name: name, label: labels[i], cfg: configs[i], chunks: make(chan inputLine)}
+		go sources[i].read()
+	}
+
+	var previous *mergeSource
+
+	for {
+		var next *mergeSource
+
+		for _, source := range sources {
+			if len(source.events) == 0 && !source.done {
+				source.advance()
+			}
+
+			if len(source.events) == 0 {
+				continue
+			}
+
+			at := source.events[0].time
+			if next == nil || at.Before(next.events[0].time) || (source == previous && at.Equal(next.events[0].time)) {
+				next = source
+			}
+		}
+
+		if next == nil {
+			break
+		}
+
+		next.print()
+		previous = next
+	}
+
+	failed := false
+
+	for _, source := range sources {
+		if source.err != nil {
+			fmt.Fprintf(os.Stderr, "error reading %s: %v\n", source.name, source.err)
+
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// fileConfig splits the options off a file argument of --merge and returns the
+// name of the file and its copy of the config. Options are only recognized by
+// their names, so colons and commas can still be part of names and values.
+func fileConfig(arg string, cfg *Config) (string, *Config, error) {
+	fileCfg := *cfg
+	name, options := splitFileOptions(arg)
+
+	for key, value := range options {
+		fileOptions[key](&fileCfg, value)
+	}
+
+	err := validateEnums(&fileCfg)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if cfg.detector != nil {
+		// Options of the file win over guesses like flags do.
+		explicit := maps.Clone(cfg.detector.explicit)
+		for key := range options {
+			explicit[key] = true
+		}
+
+		fileCfg.detector = newDetector(cfg.detector.limit, explicit)
+		fileCfg.detector.source = name
+	}
+
+	return name, &fileCfg, nil
+}
+
+// splitFileOptions splits a file argument like api.log:time=ts,time-in=Unix
+// into the name and the options.
+func splitFileOptions(arg string) (string, map[string]string) {
+	start := -1
+
+	for i := range len(arg) {
+		if arg[i] == ':' && startsWithFileOption(arg[i+1:]) {
+			start = i
+
+			break
+		}
+	}
+
+	if start == -1 {
+		return arg, nil
+	}
+
+	options := map[string]string{}
+	key := ""
+
+	// A comma only starts the next option if one follows it.
+	for _, part := range strings.Split(arg[start+1:], ",") {
+		if startsWithFileOption(part) {
+			var value string
+
+			key, value, _ = strings.Cut(part, "=")
+			options[key] = value
+		} else {
+			options[key] += "," + part
+		}
+	}
+
+	return arg[:start], options
+}
+
+// startsWithFileOption reports whether s starts with an option of fileOptions
+// and "=".
+func startsWithFileOption(s string) bool {
+	key, _, ok := strings.Cut(s, "=")
+	_, known := fileOptions[key]
+
+	return ok && known
+}
+
+// read sends the chunks of the file to the merge.
+func (s *mergeSource) read() {
+	defer close(s.chunks)
+
+	input, err := openInput(s.name)
+	if err != nil {
+		s.err = err
+
+		return
+	}
+
+	err = readChunks(input, s.label, s.cfg, func(line inputLine) {
+		s.chunks <- line
+	})
+
+	s.err = errors.Join(err, input.Close())
+}
+
+// advance decodes the next chunks of the file into events. Chunks before the
+// first event with a time are held back until it is read.
+func (s *mergeSource) advance() {
+	var held []*mergeEvent
+
+	for {
+		line, ok := <-s.chunks
+		if !ok {
+			// No event of the file has a time.
+			s.events = held
+			s.done = true
+
+			return
+		}
+
+		event := &mergeEvent{line: line, time: s.last}
+
+		entry, err := decodeInput(line, s.cfg)
+		if err == nil {
+			if s.cfg.detector != nil {
+				s.cfg.detector.observe(entry, s.cfg)
+			}
+
+			event.entry = entry
+
+			at, err := parseTime(entryTime(entry, s.cfg), s.cfg.TimeInputFormat)
+			if err == nil {
+				event.time = at
+				s.last = at
+			}
+		}
+
+		if event.time.IsZero() {
+			held = append(held, event)
+
+			continue
+		}
+
+		for _, early := range held {
+			early.time = event.time
+		}
+
+		s.events = append(held, event)
+
+		return
+	}
+}
+
+// print renders the next event of the source.
+func (s *mergeSource) print() {
+	event := s.events[0]
+
+	if event.entry == nil {
+		renderUnstructured(event.line, s.cfg)
+	} else {
+		renderEntry(event.entry, event.line.source, s.cfg)
+	}
+
+	s.events = s.events[1:]
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestSplitFileOptions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		arg     string
+		name    string
+		options map[string]string
+	}{
+		{arg: "api.log", name: "api.log"},
+		{arg: "api.log:time=ts", name: "api.log", options: map[string]string{"time": "ts"}},
+		{
+			arg:     "api.log:time=ts,time-in=2006-01-02 15:04:05,000,input=logfmt",
+			name:    "api.log",
+			options: map[string]string{"time": "ts", "time-in": "2006-01-02 15:04:05,000", "input": "logfmt"},
+		},
+		{arg: "logs/10:00.log", name: "logs/10:00.log"},
+		{arg: "a:b.log:level=severity", name: "a:b.log", options: map[string]string{"level": "severity"}},
+		{arg: "api.log:colour=red", name: "api.log:colour=red"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.arg, func(t *testing.T) {
+			t.Parallel()
+
+			name, options := splitFileOptions(testCase.arg)
+			if name != testCase.name || !maps.Equal(options, testCase.options) {
+				t.Errorf("splitFileOptions(%q) = %q, %v; want %q, %v", testCase.arg, name, options, testCase.name, testCase.options)
+			}
+		})
+	}
+}
+
+func TestFileConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig()
+
+	_, _, err := fileConfig("api.log:input=yaml", cfg)
+	if err == nil {
+		t.Error("expected an error for an unknown input format")
+	}
+
+	name, fileCfg, err := fileConfig("api.log:message=Body", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "api.log" || fileCfg.MessageKey != "Body" || cfg.MessageKey != "msg" {
+		t.Errorf("got %q with message key %q and global %q", name, fileCfg.MessageKey, cfg.MessageKey)
+	}
+}
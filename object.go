@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrNotAnObject  = errors.New("not a JSON object")
+	ErrTrailingData = errors.New("trailing data after JSON object")
+)
+
+// object is a JSON object that remembers the order in which its keys were
+// added. Nested objects are decoded as *object as well, so every level of an
+// entry keeps the order the logger emitted it in.
+type object struct {
+	order  []string
+	values map[string]any
+}
+
+func newObject() *object {
+	return &object{values: map[string]any{}}
+}
+
+// Get returns the value stored under key.
+func (o *object) Get(key string) (any, bool) {
+	value, ok := o.values[key]
+
+	return value, ok
+}
+
+// Set stores a value. New keys are appended, existing keys keep their position.
+func (o *object) Set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.order = append(o.order, key)
+	}
+
+	o.values[key] = value
+}
+
+// Delete removes a key. Deleting a missing key is a no-op.
+func (o *object) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+
+	delete(o.values, key)
+
+	for i, k := range o.order {
+		if k == key {
+			o.order = append(o.order[:i], o.order[i+1:]...)
+
+			break
+		}
+	}
+}
+
+// Keys returns the keys in insertion order.
+func (o *object) Keys() []string {
+	return o.order
+}
+
+// Len returns the number of keys.
+func (o *object) Len() int {
+	return len(o.order)
+}
+
+// Clone returns a copy of the object. Nested objects are copied as well, other
+// values are shared.
+func (o *object) Clone() *object {
+	clone := &object{order: slices.Clone(o.order), values: make(map[string]any, len(o.values))}
+
+	for key, value := range o.values {
+		if child, ok := value.(*object); ok {
+			value = child.Clone()
+		}
+
+		clone.values[key] = value
+	}
+
+	return clone
+}
+
+// MarshalJSON encodes the object with its keys in insertion order.
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i, key := range o.order {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			return nil, fmt.Errorf("can not encode key %q: %w", key, err)
+		}
+
+		valueBytes, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, fmt.Errorf("can not encode value of %q: %w", key, err)
+		}
+
+		buf.Write(keyBytes)
+		buf.WriteByte(':')
+		buf.Write(valueBytes)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// parseObject decodes a single JSON object from line.
+//
+// Numbers become float64, arrays []any and objects *object.
+func parseObject(line string) (*object, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("can not read JSON: %w", err)
+	}
+
+	if token != json.Delim('{') {
+		return nil, ErrNotAnObject
+	}
+
+	obj, err := decodeObject(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dec.Token()
+	if !errors.Is(err, io.EOF) {
+		return nil, ErrTrailingData
+	}
+
+	return obj, nil
+}
+
+// decodeObject reads the members of an object whose opening brace has already
+// been consumed.
+func decodeObject(dec *json.Decoder) (*object, error) {
+	obj := newObject()
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("can not read key: %w", err)
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return nil, ErrNotAnObject
+		}
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.Set(key, value)
+	}
+
+	// closing brace
+	_, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("can not read end of object: %w", err)
+	}
+
+	return obj, nil
+}
+
+// decodeValue reads the next JSON value.
+func decodeValue(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("can not read value: %w", err)
+	}
+
+	switch token {
+	case json.Delim('{'):
+		return decodeObject(dec)
+	case json.Delim('['):
+		values := []any{}
+
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		// closing bracket
+		_, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("can not read end of array: %w", err)
+		}
+
+		return values, nil
+	default:
+		return token, nil
+	}
+}
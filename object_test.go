@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseObject(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Keeps key order",
+			input:    `{"b":1,"a":{"d":[1,{"z":null,"y":"x"}],"c":true}}`,
+			expected: `{"b":1,"a":{"d":[1,{"z":null,"y":"x"}],"c":true}}`,
+		},
+		{
+			name:     "Duplicate keys keep first position and last value",
+			input:    `{"a":1,"b":2,"a":3}`,
+			expected: `{"a":3,"b":2}`,
+		},
+		{
+			name:     "Empty object",
+			input:    ` {} `,
+			expected: `{}`,
+		},
+		{
+			name:    "Array is not an object",
+			input:   `[1,2]`,
+			wantErr: true,
+		},
+		{
+			name:    "Null is not an object",
+			input:   `null`,
+			wantErr: true,
+		},
+		{
+			name:    "Trailing data",
+			input:   `{"a":1} {"b":2}`,
+			wantErr: true,
+		},
+		{
+			name:    "Broken JSON",
+			input:   `{"a":`,
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := parseObject(testCase.input)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("parseObject() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if testCase.wantErr {
+				return
+			}
+
+			actual, err := json.Marshal(obj)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("parseObject(%q) = %s; want %s", testCase.input, actual, testCase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A path addresses a value inside of an entry. It is either
+//
+//   - a key: "msg"
+//   - a dotted path: "log.level" or "items.0.price", where `\.` is a literal dot
+//   - a JSON pointer (RFC 6901): "/log/level"
+//
+// A key that exists literally wins over the dotted interpretation, so flat
+// keys like "log.level" keep working.
+
+// splitPath returns the keys a path consists of.
+func splitPath(path string) []string {
+	if strings.HasPrefix(path, "/") {
+		parts := strings.Split(path[1:], "/")
+		for i, part := range parts {
+			parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		}
+
+		return parts
+	}
+
+	var (
+		parts   []string
+		current strings.Builder
+	)
+
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && (path[i+1] == '.' || path[i+1] == '\\'):
+			i++
+			current.WriteByte(path[i])
+		case path[i] == '.':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+
+	return append(parts, current.String())
+}
+
+// lookup returns the value at path.
+func lookup(entry *object, path string) (any, bool) {
+	if value, ok := entry.Get(path); ok {
+		return value, true
+	}
+
+	var current any = entry
+
+	for _, key := range splitPath(path) {
+		switch node := current.(type) {
+		case *object:
+			value, ok := node.Get(key)
+			if !ok {
+				return nil, false
+			}
+
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// remove deletes the value at path. Objects that become empty by this are
+// removed as well. Array elements can not be removed.
+func remove(entry *object, path string) bool {
+	if _, ok := entry.Get(path); ok {
+		entry.Delete(path)
+
+		return true
+	}
+
+	keys := splitPath(path)
+	parents := []*object{entry}
+
+	for _, key := range keys[:len(keys)-1] {
+		value, ok := parents[len(parents)-1].Get(key)
+		if !ok {
+			return false
+		}
+
+		child, ok := value.(*object)
+		if !ok {
+			return false
+		}
+
+		parents = append(parents, child)
+	}
+
+	last := keys[len(keys)-1]
+	if _, ok := parents[len(parents)-1].Get(last); !ok {
+		return false
+	}
+
+	parents[len(parents)-1].Delete(last)
+
+	for i := len(parents) - 1; i > 0 && parents[i].Len() == 0; i-- {
+		parents[i-1].Delete(keys[i-1])
+	}
+
+	return true
+}
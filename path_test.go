@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path     string
+		expected []string
+	}{
+		{path: "msg", expected: []string{"msg"}},
+		{path: "log.level", expected: []string{"log", "level"}},
+		{path: `k8s\.io.name`, expected: []string{"k8s.io", "name"}},
+		{path: `a\\.b`, expected: []string{`a\`, "b"}},
+		{path: "/log/level", expected: []string{"log", "level"}},
+		{path: "/a~1b/c~0d", expected: []string{"a/b", "c~d"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.path, func(t *testing.T) {
+			t.Parallel()
+
+			actual := splitPath(testCase.path)
+			if !slices.Equal(actual, testCase.expected) {
+				t.Errorf("splitPath(%q) = %q; want %q", testCase.path, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	entry, err := parseObject(`{"log.level":"flat","log":{"level":"nested","origin":{"file":"a.go"}},"items":[{"id":1}],"k8s.io":{"name":"pod"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		path     string
+		expected any
+		ok       bool
+	}{
+		{path: "log.level", expected: "flat", ok: true},
+		{path: "/log/level", expected: "nested", ok: true},
+		{path: "log.origin.file", expected: "a.go", ok: true},
+		{path: "items.0.id", expected: 1.0, ok: true},
+		{path: "/items/0/id", expected: 1.0, ok: true},
+		{path: `k8s\.io.name`, expected: "pod", ok: true},
+		{path: "items.1.id", ok: false},
+		{path: "log.level.deeper", ok: false},
+		{path: "missing", ok: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.path, func(t *testing.T) {
+			t.Parallel()
+
+			actual, ok := lookup(entry, testCase.path)
+			if ok != testCase.ok || actual != testCase.expected {
+				t.Errorf("lookup(%q) = %v, %v; want %v, %v", testCase.path, actual, ok, testCase.expected, testCase.ok)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	const input = `{"log":{"level":"info","origin":{"file":"a.go","line":3}},"items":[1]}`
+
+	testCases := []struct {
+		name     string
+		input    string
+		path     string
+		expected string
+	}{
+		{
+			name:     "Nested key",
+			input:    input,
+			path:     "log.origin.line",
+			expected: `{"log":{"level":"info","origin":{"file":"a.go"}},"items":[1]}`,
+		},
+		{
+			name:     "Slash path",
+			input:    input,
+			path:     "/log/origin/file",
+			expected: `{"log":{"level":"info","origin":{"line":3}},"items":[1]}`,
+		},
+		{
+			name:     "Array elements stay",
+			input:    input,
+			path:     "items.0",
+			expected: input,
+		},
+		{
+			name:     "Empty parents are removed",
+			input:    `{"log":{"level":"info","origin":{"file":"a.go"}},"items":[1]}`,
+			path:     "log.origin.file",
+			expected: `{"log":{"level":"info"},"items":[1]}`,
+		},
+		{
+			name:     "Emptied objects are removed up the tree",
+			input:    `{"a":{"b":{"c":1}},"d":2}`,
+			path:     "a.b.c",
+			expected: `{"d":2}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			entry, err := parseObject(testCase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			remove(entry, testCase.path)
+
+			actual, _ := json.Marshal(entry)
+			if string(actual) != testCase.expected {
+				t.Errorf("remove(%q) = %s; want %s", testCase.path, actual, testCase.expected)
+			}
+		})
+	}
+}
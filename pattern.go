@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const regexPrefix = "re:"
+
+// ErrBadKeyPattern is returned for patterns of --show and --hide that aren't
+// valid globs.
+var ErrBadKeyPattern = errors.New("bad key pattern")
+
+// keyPattern selects properties by their path. Every key of the path may be a
+// glob like "http.*" or "*_ms", see path.Match for the syntax.
+//
+// Patterns starting with "re:" are regular expressions instead. They match the
+// dotted path of properties at any depth, e.g. "re:_id$" matches "trace_id" and
+// "resource.service_id".
+type keyPattern struct {
+	text string
+	keys []string
+	re   *regexp.Regexp
+}
+
+// isKeyPattern reports whether a path of --hide is a pattern rather than a
+// plain path.
+func isKeyPattern(text string) bool {
+	return strings.HasPrefix(text, regexPrefix) || strings.ContainsAny(text, "*?[")
+}
+
+// newKeyPatterns parses the patterns of an option.
+func newKeyPatterns(option string, patterns []string) ([]keyPattern, error) {
+	keyPatterns := make([]keyPattern, 0, len(patterns))
+
+	for _, text := range patterns {
+		if expression, ok := strings.CutPrefix(text, regexPrefix); ok {
+			re, err := regexp.Compile(expression)
+			if err != nil {
+				return nil, fmt.Errorf("--%s %q: %w", option, text, err)
+			}
+
+			keyPatterns = append(keyPatterns, keyPattern{text: text, re: re})
+
+			continue
+		}
+
+		keys := splitPath(text)
+
+		for _, key := range keys {
+			_, err := path.Match(key, "")
+			if err != nil {
+				return nil, fmt.Errorf("--%s %q: %w", option, text, ErrBadKeyPattern)
+			}
+		}
+
+		keyPatterns = append(keyPatterns, keyPattern{text: text, keys: keys})
+	}
+
+	return keyPatterns, nil
+}
+
+// match reports whether the property at keys is selected. Like paths, patterns
+// match flat keys such as "log.level" as a whole.
+func (p keyPattern) match(keys []string) bool {
+	if p.re != nil {
+		return p.re.MatchString(strings.Join(keys, "."))
+	}
+
+	if len(keys) == 1 {
+		if ok, _ := path.Match(p.text, keys[0]); ok {
+			return true
+		}
+	}
+
+	return len(keys) == len(p.keys) && p.matchPrefix(keys)
+}
+
+// matchBelow reports whether properties inside of the object at keys can be
+// selected.
+func (p keyPattern) matchBelow(keys []string) bool {
+	if p.re != nil {
+		return true
+	}
+
+	return len(keys) < len(p.keys) && p.matchPrefix(keys)
+}
+
+func (p keyPattern) matchPrefix(keys []string) bool {
+	for i, key := range keys {
+		if ok, _ := path.Match(p.keys[i], key); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// keepProperties removes all properties of obj the patterns don't select.
+// Objects with selected properties inside of them are kept with only these.
+func keepProperties(obj *object, parent []string, patterns []keyPattern) {
+	for _, key := range slices.Clone(obj.Keys()) {
+		keys := append(slices.Clip(parent), key)
+
+		if slices.ContainsFunc(patterns, func(p keyPattern) bool { return p.match(keys) }) {
+			continue
+		}
+
+		value, _ := obj.Get(key)
+
+		child, ok := value.(*object)
+		if ok && slices.ContainsFunc(patterns, func(p keyPattern) bool { return p.matchBelow(keys) }) {
+			keepProperties(child, keys, patterns)
+
+			if child.Len() > 0 {
+				continue
+			}
+		}
+
+		obj.Delete(key)
+	}
+}
+
+// dropProperties removes all properties of obj the patterns select. Objects
+// that become empty by this are removed as well.
+func dropProperties(obj *object, parent []string, patterns []keyPattern) {
+	for _, key := range slices.Clone(obj.Keys()) {
+		keys := append(slices.Clip(parent), key)
+
+		if slices.ContainsFunc(patterns, func(p keyPattern) bool { return p.match(keys) }) {
+			obj.Delete(key)
+
+			continue
+		}
+
+		value, _ := obj.Get(key)
+
+		child, ok := value.(*object)
+		if ok && child.Len() > 0 && slices.ContainsFunc(patterns, func(p keyPattern) bool { return p.matchBelow(keys) }) {
+			dropProperties(child, keys, patterns)
+
+			if child.Len() == 0 {
+				obj.Delete(key)
+			}
+		}
+	}
+}
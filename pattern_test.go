@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestKeepProperties(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		patterns []string
+		expected string
+	}{
+		{patterns: []string{"user"}, expected: `{"user":{"id":7,"name":"Ann"}}`},
+		{patterns: []string{"user.id"}, expected: `{"user":{"id":7}}`},
+		{patterns: []string{"/user/name"}, expected: `{"user":{"name":"Ann"}}`},
+		{patterns: []string{"http.*"}, expected: `{"http":{"method":"GET","status":200},"http.route":"/"}`},
+		{patterns: []string{"*_ms"}, expected: `{"took_ms":12}`},
+		{patterns: []string{"log.level"}, expected: `{"log.level":"warn"}`},
+		{patterns: []string{"user.*", "took_ms"}, expected: `{"user":{"id":7,"name":"Ann"},"took_ms":12}`},
+		{patterns: []string{"user.email"}, expected: `{}`},
+		{patterns: []string{"tags.0"}, expected: `{}`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			t.Parallel()
+
+			entry, err := parseObject(`{"user":{"id":7,"name":"Ann"},"http":{"method":"GET","status":200},` +
+				`"http.route":"/","took_ms":12,"log.level":"warn","tags":["a"]}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patterns, err := newKeyPatterns("show", testCase.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keepProperties(entry, nil, patterns)
+
+			actual, err := json.Marshal(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("%q kept %s, expected %s", testCase.patterns, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestDropProperties(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		patterns []string
+		expected string
+	}{
+		{patterns: []string{"otel.*"}, expected: `{"k8s.pod":"api-1","resource":{"service_id":"s1","host":{"name":"h1","arch":"amd64"}},"took_ms":12}`},
+		{patterns: []string{"*_ms", "k8s.*"}, expected: `{"otel":{"trace_id":"t1"},"otel.span_id":"s2","resource":{"service_id":"s1","host":{"name":"h1","arch":"amd64"}}}`},
+		{patterns: []string{"resource.host.*"}, expected: `{"otel":{"trace_id":"t1"},"otel.span_id":"s2","k8s.pod":"api-1","resource":{"service_id":"s1"},"took_ms":12}`},
+		{patterns: []string{"re:_id$"}, expected: `{"k8s.pod":"api-1","resource":{"host":{"name":"h1","arch":"amd64"}},"took_ms":12}`},
+		{patterns: []string{"re:^resource\\.host\\.(name|arch)$"}, expected: `{"otel":{"trace_id":"t1"},"otel.span_id":"s2","k8s.pod":"api-1","resource":{"service_id":"s1"},"took_ms":12}`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			t.Parallel()
+
+			entry, err := parseObject(`{"otel":{"trace_id":"t1"},"otel.span_id":"s2","k8s.pod":"api-1",` +
+				`"resource":{"service_id":"s1","host":{"name":"h1","arch":"amd64"}},"took_ms":12}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patterns, err := newKeyPatterns("hide", testCase.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dropProperties(entry, nil, patterns)
+
+			actual, err := json.Marshal(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(actual) != testCase.expected {
+				t.Errorf("%q left %s, expected %s", testCase.patterns, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestBadKeyPattern(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{"user.[a", "re:(a"} {
+		_, err := newKeyPatterns("hide", []string{pattern})
+		if err == nil {
+			t.Errorf("expected an error for %q", pattern)
+		}
+	}
+}
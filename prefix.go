@@ -0,0 +1,84 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// servicePrefix matches the prefixes docker compose and process managers like
+// foreman and overmind put in front of every line of a service, like
+// "api-1  | " or "12:00:01 web.1  | ". The names are padded to the same width,
+// which the labels keep.
+//
+// Docker compose pads even the longest name with two spaces, so without the
+// time of a process manager a single space before the pipe isn't a prefix:
+// "foo | x=1" is text.
+var servicePrefix = regexp.MustCompile(`^(?:\d{2}:\d{2}:\d{2}\s+([\w.-]+\s*)|([\w.-]+\s+))\s\| ?`)
+
+// prefixes takes the prefixes of services off lines.
+//
+// A prefix is only taken off if the rest of the line is an event, so plain text
+// with a pipe in it stays as it is. Once a service has written an event, all
+// of its lines are taken off their prefix, so its stack traces are grouped as
+// usual.
+type prefixes struct {
+	// services holds the services that have written an event.
+	services map[string]bool
+	// labels are the names of the services with the padding of their prefix,
+	// so they line up like the prefixes did.
+	labels map[string]string
+}
+
+func newPrefixes() *prefixes {
+	return &prefixes{services: map[string]bool{}, labels: map[string]string{}}
+}
+
+// cut returns the line without its prefix and the service, or the line as it
+// is and "".
+func (p *prefixes) cut(line string) (string, string) {
+	// Prefixes are colored on a terminal.
+	plain := line
+	if strings.Contains(line, "\x1b") {
+		plain = escapeSequence.ReplaceAllString(line, "")
+	}
+
+	match := servicePrefix.FindStringSubmatchIndex(plain)
+	if match == nil {
+		return line, ""
+	}
+
+	// Either the name after a time or the name alone matched.
+	start, end := match[2], match[3]
+	if start == -1 {
+		start, end = match[4], match[5]
+	}
+
+	padded := plain[start:end]
+
+	service, payload := strings.TrimRight(padded, " \t"), plain[match[1]:]
+
+	if !p.services[service] && !isEvent(payload) {
+		return line, ""
+	}
+
+	p.services[service] = true
+	p.labels[service] = padded
+
+	return payload, service
+}
+
+// label returns the name of a service padded like its last prefix.
+func (p *prefixes) label(service string) string {
+	return p.labels[service]
+}
+
+// isEvent reports whether text starts a JSON object or is a logfmt line.
+func isEvent(text string) bool {
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		return true
+	}
+
+	_, err := parseLogfmt(text, false)
+
+	return err == nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestPrefixes(t *testing.T) {
+	t.Parallel()
+
+	p := newPrefixes()
+
+	testCases := []struct {
+		line    string
+		payload string
+		service string
+	}{
+		{line: `api-1     | {"level":"INFO"}`, payload: `{"level":"INFO"}`, service: "api-1"},
+		{line: "\x1b[36mworker-1  |\x1b[0m level=info msg=hi", payload: "level=info msg=hi", service: "worker-1"},
+		{line: `12:00:01 web.1  | {"msg":"foreman"}`, payload: `{"msg":"foreman"}`, service: "web.1"},
+		// Plain text only loses its prefix once the service wrote an event.
+		{line: "api-1     | panic: boom", payload: "panic: boom", service: "api-1"},
+		{line: "12:00:01 system | web.1 started with pid 42", payload: "12:00:01 system | web.1 started with pid 42"},
+		{line: "cat | grep", payload: "cat | grep"},
+		{line: `api-1| {"msg":"no space"}`, payload: `api-1| {"msg":"no space"}`},
+		// A single space is only a prefix after the time of a process manager.
+		{line: "foo | x=1", payload: "foo | x=1"},
+		{line: `12:00:02 worker.1 | {"msg":"longest"}`, payload: `{"msg":"longest"}`, service: "worker.1"},
+	}
+
+	for _, testCase := range testCases {
+		payload, service := p.cut(testCase.line)
+		if payload != testCase.payload || service != testCase.service {
+			t.Errorf("cut(%q) = %q, %q; want %q, %q", testCase.line, payload, service, testCase.payload, testCase.service)
+		}
+	}
+
+	// Labels keep the padding of their prefix, so later services with longer
+	// names don't change them.
+	for service, expected := range map[string]string{"api-1": "api-1    ", "web.1": "web.1 ", "worker.1": "worker.1"} {
+		if label := p.label(service); label != expected {
+			t.Errorf("label(%q) = %q; want %q", service, label, expected)
+		}
+	}
+}
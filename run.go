@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// inputLine is a line of input together with the name of the stream it came
+// from.
+type inputLine struct {
+	text   string
+	source string
+	// stream is the stream of an unwrapped container log line.
+	stream string
+}
+
+// run starts command, renders everything it writes to stdout and stderr and
+// returns its exit status.
+//
+// axt survives interrupts, SIGTERM and SIGHUP, so it keeps rendering the last
+// words of the child while it shuts down, and forwards them to the child. The
+// child runs in its own process group, so it gets Ctrl-C from the terminal only
+// once, from axt; a second interrupt would make many programs skip their
+// graceful shutdown. Outside that group the child can't read from the
+// terminal, so it only gets stdin if that is a pipe or file.
+func run(command []string, cfg *Config) int {
+	cmd := exec.Command(command[0], command[1:]...) //nolint:gosec // running the user's command is the point
+	cmd.SysProcAttr = childAttr()
+
+	if !isTerminal(os.Stdin) {
+		cmd.Stdin = os.Stdin
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error starting %s: %v\n", command[0], err)
+
+		return 1
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error starting %s: %v\n", command[0], err)
+
+		return 1
+	}
+
+	// Signals are caught before the child starts, it may send some right away.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+
+	defer func() {
+		signal.Stop(signals)
+		close(signals)
+	}()
+
+	err = cmd.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error starting %s: %v\n", command[0], err)
+
+		return 127
+	}
+
+	go func() {
+		for sig := range signals {
+			forwardSignal(cmd.Process, sig)
+		}
+	}()
+
+	lines := make(chan inputLine)
+
+	var readers sync.WaitGroup
+
+	for source, reader := range map[string]io.Reader{streamStdout: stdout, streamStderr: stderr} {
+		readers.Add(1)
+
+		go func() {
+			defer readers.Done()
+
+			err := readChunks(reader, source, cfg, func(line inputLine) {
+				lines <- line
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error reading %s: %v\n", source, err)
+				// Keep the child from blocking on a full pipe.
+				_, _ = io.Copy(io.Discard, reader)
+			}
+		}()
+	}
+
+	go func() {
+		readers.Wait()
+		close(lines)
+	}()
+
+	for line := range lines {
+		render(line, cfg)
+	}
+
+	return exitCode(cmd.Wait())
+}
+
+// exitCode translates the result of cmd.Wait into an exit status. Like a
+// shell, a child killed by a signal results in 128 + the signal number.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		fmt.Fprintf(os.Stderr, "error waiting for command: %v\n", err)
+
+		return 1
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return exitErr.ExitCode()
+}
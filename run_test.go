@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	testCases := []struct {
+		name     string
+		script   string
+		expected int
+	}{
+		{name: "Success", script: "exit 0", expected: 0},
+		{name: "Failure", script: "exit 3", expected: 3},
+		{name: "Killed by signal", script: "kill -TERM $$", expected: 143},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := exitCode(exec.Command("sh", "-c", testCase.script).Run())
+			if actual != testCase.expected {
+				t.Errorf("exitCode() = %d; want %d", actual, testCase.expected)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestRunCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+	script := `echo '{"time":"2025-08-24T21:51:45.549Z","level":"INFO","msg":"Listening"}'; echo 'panic: oh no' >&2`
+	actual := stripAnsi(captureOutput(t, []string{"--", "sh", "-c", script}, ""))
+
+	for _, expected := range []string{
+		"stdout 21:51:45.549   INFO   Listening",
+		"stderr 🪵  panic: oh no",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("Expected line not found in output.\n--- Missing Line ---\n%s\n--- Actual Output ---\n%s", expected, actual)
+		}
+	}
+}
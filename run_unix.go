@@ -0,0 +1,35 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// forwardedSignals are passed on to the child. Stopping and continuing are
+// passed on too, so Ctrl-Z and fg reach it like they would in a shell.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGTSTP, syscall.SIGCONT}
+
+// childAttr puts the child into a process group of its own, so Ctrl-C in the
+// terminal only reaches axt, which forwards it once.
+func childAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// forwardSignal sends a signal to the process group of the child, so processes
+// it started get it as well. On Ctrl-Z axt stops itself after the child, which
+// the terminal would have done without the caught signal.
+func forwardSignal(process *os.Process, sig os.Signal) {
+	number, ok := sig.(syscall.Signal)
+	if !ok {
+		return
+	}
+
+	// The child may already be gone; nothing left to do then.
+	_ = syscall.Kill(-process.Pid, number)
+
+	if number == syscall.SIGTSTP {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+	}
+}
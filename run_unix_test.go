@@ -0,0 +1,103 @@
+//go:build !windows
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+//nolint:paralleltest // meddles with system globals and can't run in parallel
+func TestRunSignals(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	testCases := []struct {
+		name   string
+		signal syscall.Signal
+		trap   string
+	}{
+		{name: "Interrupts are forwarded", signal: syscall.SIGINT, trap: "INT"},
+		{name: "SIGTERM is forwarded", signal: syscall.SIGTERM, trap: "TERM"},
+		{name: "SIGHUP is forwarded", signal: syscall.SIGHUP, trap: "HUP"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// The child says when its trap is set and gives up after a few
+			// seconds if the signal never comes.
+			script := `trap 'echo "{\"msg\":\"received\"}"; exit 0' ` + testCase.trap + `
+echo '{"msg":"ready"}'
+i=0; while [ $i -lt 500 ]; do sleep 0.01; i=$((i+1)); done`
+
+			lines := runInBackground(t, []string{"sh", "-c", script})
+
+			for line := range lines {
+				switch {
+				case strings.Contains(line, "ready"):
+					err := syscall.Kill(os.Getpid(), testCase.signal)
+					if err != nil {
+						t.Fatal(err)
+					}
+				case strings.Contains(line, "received"):
+					return
+				}
+			}
+
+			t.Errorf("The child didn't get %v", testCase.signal)
+		})
+	}
+}
+
+// runInBackground runs a command like axt -- command and returns the lines it
+// prints. The channel is closed when the command is done.
+func runInBackground(t *testing.T, command []string) <-chan string {
+	t.Helper()
+
+	oldStdout := os.Stdout
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Stdout = writer
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		run(command, newConfig())
+		_ = writer.Close()
+	}()
+
+	t.Cleanup(func() {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Error("the command didn't finish")
+		}
+
+		os.Stdout = oldStdout
+		_ = reader.Close()
+	})
+
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines <- stripAnsi(scanner.Text())
+		}
+	}()
+
+	return lines
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// forwardedSignals are passed on to the child.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// childAttr keeps the defaults; Windows has no process groups to separate.
+func childAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// forwardSignal sends a signal to the child.
+func forwardSignal(process *os.Process, sig os.Signal) {
+	// The child may already be gone; nothing left to do then.
+	_ = process.Signal(sig)
+}
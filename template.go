@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// formatTemplate renders entries with the Go template of --format.
+type formatTemplate struct {
+	template *template.Template
+	// referenced holds the top-level properties the template uses. rest
+	// renders all others.
+	referenced map[string]bool
+	// paths are the key paths the template uses, like user.name for
+	// {{.user.name}}.
+	paths [][]string
+	// entry is the entry being rendered.
+	entry *object
+	// cfg is the config of the entry being rendered. With --merge every file
+	// has its own, with the keys, time format and level scheme of the file.
+	cfg *Config
+}
+
+// newFormatTemplate parses the template of --format.
+//
+// Besides the properties of an entry, e.g. {{.msg}}, templates can use these
+// functions:
+//
+//   - formatTime, formatLevel and formatValue format a value like axt does
+//   - theme "time" text styles text like an element of the theme
+//   - levelColor level text colors text like messages of the level
+//   - color "bold red" text styles text
+//   - rest renders all properties the template doesn't use as key=value pairs,
+//     limited to those of --show
+func newFormatTemplate(text string) (*formatTemplate, error) {
+	f := &formatTemplate{referenced: map[string]bool{}}
+
+	funcs := template.FuncMap{
+		"formatTime": func(value any) string {
+			return formatTime(templateString(value), f.cfg.TimeInputFormat, f.cfg.TimeOutputFormat)
+		},
+		"formatLevel": func(value any) string {
+			level, _ := formatLevel(levelName(templateNumber(value), f.cfg.LevelScheme), f.cfg)
+
+			return level
+		},
+		"formatValue": func(value any) string {
+			return formatValue(value, f.cfg)
+		},
+		"theme": func(element string, value any) (string, error) {
+			s, ok := themeElements(f.cfg.theme)[element]
+			if !ok {
+				return "", fmt.Errorf("%w: unknown element %q", ErrBadTheme, element)
+			}
+
+			return s.Sprint(templateString(value)), nil
+		},
+		"levelColor": func(level, value any) string {
+			info, _ := f.cfg.levelTable.get(levelName(templateNumber(level), f.cfg.LevelScheme))
+
+			return info.MainColor.Sprint(templateString(value))
+		},
+		"color": func(spec string, value any) (string, error) {
+			s, err := parseStyle(spec)
+			if err != nil {
+				return "", err
+			}
+
+			return s.Sprint(templateString(value)), nil
+		},
+		"rest": func() string {
+			// The entry is shared with the rest of the line and the layout
+			// without the template, so only a copy is narrowed down.
+			entry := f.entry
+			if len(f.cfg.show) > 0 {
+				entry = entry.Clone()
+				keepProperties(entry, nil, f.cfg.show)
+			}
+
+			var keys []string
+
+			for _, key := range entry.Keys() {
+				if !f.referenced[key] {
+					keys = append(keys, key)
+				}
+			}
+
+			// The whole line is highlighted at the end.
+			plain := *f.cfg
+			plain.highlight = nil
+
+			// Where the properties end up in the line isn't known, so at most
+			// a full line is written.
+			return strings.TrimPrefix(formatCompactProperties(entry, keys, 0, &plain), " ")
+		},
+	}
+
+	tmpl, err := template.New("format").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("--format: %w", err)
+	}
+
+	f.template = tmpl
+	collectFields(tmpl.Root, &f.paths)
+
+	for _, path := range f.paths {
+		f.referenced[path[0]] = true
+	}
+
+	return f, nil
+}
+
+// collectFields adds the key paths used by the nodes of a template: fields like
+// .user.name and keys of the index function like index . "log.level".
+func collectFields(node parse.Node, paths *[][]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			collectFields(child, paths)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, paths)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		for _, cmd := range n.Cmds {
+			collectFields(cmd, paths)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) >= 3 && n.Args[0].String() == "index" && n.Args[1].Type() == parse.NodeDot {
+			if key, ok := n.Args[2].(*parse.StringNode); ok {
+				*paths = append(*paths, []string{key.Text})
+			}
+		}
+
+		for _, arg := range n.Args {
+			collectFields(arg, paths)
+		}
+	case *parse.FieldNode:
+		*paths = append(*paths, n.Ident)
+	case *parse.VariableNode:
+		// $.user refers to the entry.
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			*paths = append(*paths, n.Ident[1:])
+		}
+	case *parse.ChainNode:
+		collectFields(n.Node, paths)
+	case *parse.IfNode:
+		collectBranch(&n.BranchNode, paths)
+	case *parse.RangeNode:
+		collectBranch(&n.BranchNode, paths)
+	case *parse.WithNode:
+		collectBranch(&n.BranchNode, paths)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, paths)
+	}
+}
+
+func collectBranch(branch *parse.BranchNode, paths *[][]string) {
+	collectFields(branch.Pipe, paths)
+	collectFields(branch.List, paths)
+	collectFields(branch.ElseList, paths)
+}
+
+// print renders an entry with the template. It returns false if the template
+// fails on the entry, so the entry can be rendered without it.
+func (f *formatTemplate) print(entry *object, source string, cfg *Config) bool {
+	line, err := f.execute(entry, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "axt: %v\n", err)
+
+		return false
+	}
+
+	fmt.Printf("%s%s\n%s", formatSource(source, cfg), line, formatNewLine(cfg.EmptyLineStrategy, true))
+
+	return true
+}
+
+// execute renders an entry and highlights the line. Properties the template
+// uses but the entry lacks are empty instead of "<no value>".
+func (f *formatTemplate) execute(entry *object, cfg *Config) (string, error) {
+	f.entry = entry
+	f.cfg = cfg
+
+	data, _ := templateData(entry).(map[string]any)
+	for _, path := range f.paths {
+		fillPath(data, path)
+	}
+
+	var line strings.Builder
+
+	err := f.template.Execute(&line, data)
+	if err != nil {
+		return "", fmt.Errorf("can not render --format: %w", err)
+	}
+
+	return cfg.highlight.apply(line.String()), nil
+}
+
+// fillPath adds a key path the entry lacks with an empty value at its end.
+// Missing or null parents become maps. Paths through other values are left
+// alone.
+func fillPath(data map[string]any, path []string) {
+	for i, key := range path {
+		if i == len(path)-1 {
+			if _, ok := data[key]; !ok {
+				data[key] = ""
+			}
+
+			return
+		}
+
+		switch child := data[key].(type) {
+		case map[string]any:
+			data = child
+		case nil:
+			parent := map[string]any{}
+			data[key] = parent
+			data = parent
+		default:
+			return
+		}
+	}
+}
+
+// templateData turns an entry into maps templates can walk through. Whole
+// numbers become integers so they aren't printed in exponent notation.
+func templateData(value any) any {
+	switch v := value.(type) {
+	case *object:
+		data := make(map[string]any, v.Len())
+
+		for _, key := range v.Keys() {
+			child, _ := v.Get(key)
+			data[key] = templateData(child)
+		}
+
+		return data
+	case []any:
+		data := make([]any, len(v))
+
+		for i, child := range v {
+			data[i] = templateData(child)
+		}
+
+		return data
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+
+		return v
+	default:
+		return value
+	}
+}
+
+// templateNumber turns the integers of templateData back into float64.
+func templateNumber(value any) any {
+	if number, ok := value.(int64); ok {
+		return float64(number)
+	}
+
+	return value
+}
+
+// templateString returns the text of a template value.
+func templateString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
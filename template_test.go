@@ -0,0 +1,193 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pterm/pterm"
+)
+
+func TestFormatTemplateFields(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		format   string
+		expected []string
+		wantErr  bool
+	}{
+		{name: "Fields", format: `{{.time}} {{.msg}}`, expected: []string{"msg", "time"}},
+		{name: "Nested fields", format: `{{.user.name}}`, expected: []string{"user"}},
+		{name: "Functions and pipes", format: `{{formatTime .ts}} {{.msg | color "red"}}`, expected: []string{"msg", "ts"}},
+		{name: "Index", format: `{{index . "log.level"}}`, expected: []string{"log.level"}},
+		{name: "Branches", format: `{{if .err}}{{.err}}{{else}}{{$.ok}}{{end}}`, expected: []string{"err", "ok"}},
+		{name: "Rest only", format: `{{rest}}`, expected: []string{}},
+		{name: "Parse error", format: `{{.msg`, wantErr: true},
+		{name: "Unknown function", format: `{{shout .msg}}`, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			format, err := newFormatTemplate(testCase.format)
+			if testCase.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for %q", testCase.format)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual := slices.Sorted(maps.Keys(format.referenced))
+			if !slices.Equal(actual, testCase.expected) {
+				t.Errorf("referenced fields of %q = %v; want %v", testCase.format, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestFormatTemplateMissingKeys(t *testing.T) {
+	t.Parallel()
+
+	format, err := newFormatTemplate(`{{.time}} [{{.request_id}}] {{index . "log.level"}}{{.msg}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := parseObject(`{"time":"2025-01-01T10:00:00Z","msg":"x"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := format.execute(entry, newConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "2025-01-01T10:00:00Z [] x"; actual != expected {
+		t.Errorf("rendered %q; want %q", actual, expected)
+	}
+}
+
+func TestFormatTemplateMissingNestedKeys(t *testing.T) {
+	t.Parallel()
+
+	format, err := newFormatTemplate(`[{{.user.name}}] [{{$.http.request.id}}] {{.msg}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Missing child", input: `{"user":{"id":7},"http":{},"msg":"x"}`, expected: "[] [] x"},
+		{name: "Missing parent", input: `{"msg":"x"}`, expected: "[] [] x"},
+		{name: "Null parent", input: `{"user":null,"msg":"x"}`, expected: "[] [] x"},
+		{name: "Present", input: `{"user":{"name":"Ann"},"http":{"request":{"id":"r-1"}},"msg":"x"}`, expected: "[Ann] [r-1] x"},
+	}
+
+	for _, testCase := range testCases {
+		//nolint:paralleltest // the subtests share the template
+		t.Run(testCase.name, func(t *testing.T) {
+			entry, err := parseObject(testCase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := format.execute(entry, newConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != testCase.expected {
+				t.Errorf("rendered %q; want %q", actual, testCase.expected)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // highlighting depends on the global color setting of pterm
+func TestFormatTemplateHighlight(t *testing.T) {
+	pterm.EnableColor()
+	defer pterm.DisableColor()
+
+	cfg := newConfig()
+
+	theme, err := builtinTheme(defaultTheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.highlight, err = newHighlighter([]string{`req-\d+`}, theme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	format, err := newFormatTemplate(`{{.msg}} {{rest}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := parseObject(`{"msg":"req-1 done","id":"req-2"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := format.execute(entry, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := strings.Count(actual, theme.Highlights[0].escape()); count != 2 {
+		t.Errorf("rendered %q; want 2 highlighted matches, got %d", actual, count)
+	}
+
+	if expected := "req-1 done id=req-2"; stripAnsi(actual) != expected {
+		t.Errorf("rendered %q; want %q", stripAnsi(actual), expected)
+	}
+}
+
+func TestFormatTemplateRestKeepsEntry(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig()
+
+	var err error
+
+	cfg.show, err = newKeyPatterns("show", []string{"user.id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	format, err := newFormatTemplate(`{{rest}} | {{rest}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := parseObject(`{"msg":"x","user":{"id":7,"name":"Ann"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := format.execute(entry, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `user={"id":7} | user={"id":7}`; actual != expected {
+		t.Errorf("rendered %q; want %q", actual, expected)
+	}
+
+	if name, ok := lookup(entry, "user.name"); !ok || name != "Ann" {
+		t.Errorf("rest removed user.name from the entry")
+	}
+}
@@ -0,0 +1,524 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultTheme = "dark"
+	themesDir    = "themes"
+)
+
+var (
+	ErrUnknownTheme = errors.New("unknown theme")
+	ErrBadTheme     = errors.New("bad theme")
+)
+
+// Theme holds the styles of every colored element axt prints.
+type Theme struct {
+	Time   style
+	Key    style
+	Gutter style
+	Stdout style
+	Stderr style
+	Error  style
+	// BorderOuter and BorderInner are the ends of the fade of the border around
+	// properties.
+	BorderOuter paint
+	BorderInner paint
+	JSON        jsonTheme
+	// Highlights are used in turn for the patterns of --highlight.
+	Highlights []style
+	// Sources color the labels of files, see sourceStyle.
+	Sources []style
+
+	// levels change the colors of levelMap.
+	levels []levelDefinition
+}
+
+// jsonTheme holds the styles of property values.
+type jsonTheme struct {
+	Key      style
+	String   style
+	Number   style
+	True     style
+	False    style
+	Null     style
+	Escape   style
+	Brackets style
+}
+
+// builtinThemes are written like theme files. All of them start from the dark
+// theme, which is the look of axt without a theme.
+var builtinThemes = map[string]string{
+	"dark": `
+time = "gray"
+key = "default"
+gutter = "gray"
+stdout = "gray"
+stderr = "red"
+error = "red"
+border = ["#464646", "#969696"]
+highlight = [
+  "black on yellow",
+  "black on cyan",
+  "black on magenta",
+  "black on green",
+  "black on light-red",
+  "black on light-blue",
+]
+sources = [
+  "cyan",
+  "magenta",
+  "yellow",
+  "green",
+  "light-blue",
+  "light-red",
+]
+
+[json]
+key = "default"
+string = "green"
+number = "yellow"
+true = "cyan"
+false = "cyan"
+null = "dim"
+escape = "magenta"
+brackets = "bold"
+`,
+	"light": `
+time = "#6c6c6c"
+gutter = "#6c6c6c"
+stdout = "#6c6c6c"
+border = ["#c8c8c8", "#787878"]
+sources = [
+  "#005f87",
+  "#870087",
+  "#875f00",
+  "#008700",
+  "#5f00d7",
+  "#af0000",
+]
+
+[json]
+string = "#008700"
+number = "#005fd7"
+true = "#008787"
+false = "#008787"
+null = "#8a8a8a"
+
+[levels.TRACE]
+fg = "white"
+color = "#005fd7"
+
+[levels.DEBUG]
+fg = "white"
+color = "#008700"
+
+[levels.INFO]
+fg = "white"
+
+[levels.WARN]
+color = "#af5f00"
+
+[levels.WARNING]
+color = "#af5f00"
+
+[levels.ERROR]
+fg = "white"
+
+[levels.ERR]
+fg = "white"
+
+[levels.FATAL]
+fg = "white"
+
+[levels.CRITICAL]
+fg = "white"
+`,
+	"solarized": `
+time = "#586e75"
+key = "#93a1a1"
+gutter = "#586e75"
+stdout = "#586e75"
+stderr = "#dc322f"
+error = "#dc322f"
+border = ["#073642", "#586e75"]
+highlight = [
+  "#002b36 on #b58900",
+  "#002b36 on #2aa198",
+  "#002b36 on #d33682",
+  "#002b36 on #859900",
+  "#002b36 on #cb4b16",
+  "#002b36 on #6c71c4",
+]
+sources = [
+  "#268bd2",
+  "#d33682",
+  "#b58900",
+  "#859900",
+  "#2aa198",
+  "#6c71c4",
+]
+
+[json]
+key = "#93a1a1"
+string = "#859900"
+number = "#2aa198"
+true = "#b58900"
+false = "#b58900"
+null = "#586e75"
+escape = "#d33682"
+brackets = "bold #839496"
+
+[levels.TRACE]
+bg = "#6c71c4"
+fg = "#fdf6e3"
+color = "#6c71c4"
+
+[levels.DEBUG]
+bg = "#859900"
+fg = "#fdf6e3"
+color = "#859900"
+
+[levels.INFO]
+bg = "#268bd2"
+fg = "#fdf6e3"
+color = "#93a1a1"
+
+[levels.WARN]
+bg = "#b58900"
+fg = "#fdf6e3"
+color = "#b58900"
+
+[levels.WARNING]
+bg = "#b58900"
+fg = "#fdf6e3"
+color = "#b58900"
+
+[levels.ERROR]
+bg = "#dc322f"
+fg = "#fdf6e3"
+color = "#dc322f"
+
+[levels.ERR]
+bg = "#dc322f"
+fg = "#fdf6e3"
+color = "#dc322f"
+
+[levels.FATAL]
+bg = "#d33682"
+fg = "#fdf6e3"
+color = "#d33682"
+
+[levels.CRITICAL]
+bg = "#d33682"
+fg = "#fdf6e3"
+color = "#d33682"
+`,
+	"high-contrast": `
+time = "light-white"
+key = "bold light-white"
+gutter = "light-white"
+stdout = "light-white"
+stderr = "bold light-red"
+error = "bold light-red"
+border = ["#bcbcbc", "#ffffff"]
+highlight = [
+  "bold black on light-yellow",
+  "bold black on light-cyan",
+  "bold black on light-magenta",
+  "bold black on light-green",
+]
+sources = [
+  "bold light-cyan",
+  "bold light-magenta",
+  "bold light-yellow",
+  "bold light-green",
+]
+
+[json]
+key = "bold light-white"
+string = "light-green"
+number = "light-yellow"
+true = "light-cyan"
+false = "light-cyan"
+null = "light-magenta"
+escape = "light-magenta"
+brackets = "bold light-white"
+
+[levels.TRACE]
+bg = "light-blue"
+color = "light-blue"
+
+[levels.DEBUG]
+bg = "light-green"
+color = "light-green"
+
+[levels.INFO]
+bg = "light-white"
+color = "light-white"
+
+[levels.WARN]
+bg = "light-yellow"
+color = "light-yellow"
+
+[levels.WARNING]
+bg = "light-yellow"
+color = "light-yellow"
+
+[levels.ERROR]
+bg = "light-red"
+color = "light-red"
+
+[levels.ERR]
+bg = "light-red"
+color = "light-red"
+
+[levels.FATAL]
+bg = "light-magenta"
+color = "light-magenta"
+
+[levels.CRITICAL]
+bg = "light-magenta"
+color = "light-magenta"
+`,
+}
+
+// loadTheme returns a built-in theme or reads a theme file. The name is either
+// a path or the name of a file in the themes directory next to the global
+// config, e.g. "mine" for ~/.config/axt/themes/mine.toml.
+func loadTheme(name string) (*Theme, error) {
+	if _, ok := builtinThemes[name]; ok {
+		return builtinTheme(name)
+	}
+
+	path := name
+	if dir := configDir(); !isFile(path) && dir != "" {
+		path = filepath.Join(dir, themesDir, name+".toml")
+	}
+
+	if !isFile(path) {
+		return nil, fmt.Errorf("--theme %q: %w, expected one of %s or a theme file",
+			name, ErrUnknownTheme, strings.Join(slices.Sorted(maps.Keys(builtinThemes)), ", "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read theme: %w", err)
+	}
+
+	tree, err := parseTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// User themes start from a built-in theme, so they only need to name what
+	// they change.
+	baseName := defaultTheme
+	if value, ok := tree["base"]; ok {
+		baseName, _ = value.(string)
+		if _, ok := builtinThemes[baseName]; !ok {
+			return nil, fmt.Errorf("%s: base %q: %w", path, baseName, ErrUnknownTheme)
+		}
+	}
+
+	base, err := builtinTheme(baseName)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyTheme(base, tree, path)
+}
+
+// builtinTheme returns the theme of builtinThemes with the given name.
+func builtinTheme(name string) (*Theme, error) {
+	tree, err := parseTOML(builtinThemes[name])
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %w", name, err)
+	}
+
+	base := &Theme{}
+	if name != defaultTheme {
+		base, err = builtinTheme(defaultTheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return applyTheme(base, tree, "theme "+name)
+}
+
+// applyTheme returns a copy of base with the styles of a parsed theme file.
+func applyTheme(base *Theme, tree map[string]any, origin string) (*Theme, error) {
+	theme := *base
+	theme.levels = slices.Clone(base.levels)
+
+	styles := map[string]*style{
+		"time":   &theme.Time,
+		"key":    &theme.Key,
+		"gutter": &theme.Gutter,
+		"stdout": &theme.Stdout,
+		"stderr": &theme.Stderr,
+		"error":  &theme.Error,
+	}
+
+	jsonStyles := map[string]*style{
+		"key":      &theme.JSON.Key,
+		"string":   &theme.JSON.String,
+		"number":   &theme.JSON.Number,
+		"true":     &theme.JSON.True,
+		"false":    &theme.JSON.False,
+		"null":     &theme.JSON.Null,
+		"escape":   &theme.JSON.Escape,
+		"brackets": &theme.JSON.Brackets,
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(tree)) {
+		value := tree[key]
+
+		var err error
+
+		switch key {
+		case "base":
+		case "border":
+			err = applyBorder(&theme, value)
+			if err != nil {
+				err = fmt.Errorf("%s: %s: %w", origin, key, err)
+			}
+		case "highlight":
+			theme.Highlights, err = parseStyles(value)
+			if err != nil {
+				err = fmt.Errorf("%s: %s: %w", origin, key, err)
+			}
+		case "sources":
+			theme.Sources, err = parseStyles(value)
+			if err != nil {
+				err = fmt.Errorf("%s: %s: %w", origin, key, err)
+			}
+		case "json":
+			table, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s: %w: expected a table", origin, key, ErrBadTheme)
+			}
+
+			err = applyStyles(jsonStyles, table, origin+" [json]")
+		case levelsKey:
+			var defs []levelDefinition
+
+			defs, err = parseLevelTables(value, origin)
+			theme.levels = append(theme.levels, defs...)
+		default:
+			err = applyStyles(styles, map[string]any{key: value}, origin)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &theme, nil
+}
+
+// applyStyles parses the values of table into the styles of the same name.
+func applyStyles(styles map[string]*style, table map[string]any, origin string) error {
+	for key, value := range table {
+		target, ok := styles[key]
+		if !ok {
+			return fmt.Errorf("%s: %w: unknown element %q, expected one of %s",
+				origin, ErrBadTheme, key, strings.Join(slices.Sorted(maps.Keys(styles)), ", "))
+		}
+
+		spec, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: %s: %w: expected a string", origin, key, ErrBadTheme)
+		}
+
+		s, err := parseStyle(spec)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", origin, key, err)
+		}
+
+		*target = s
+	}
+
+	return nil
+}
+
+// parseStyles parses an array of styles.
+func parseStyles(value any) ([]style, error) {
+	specs, ok := value.([]any)
+	if !ok || len(specs) == 0 {
+		return nil, fmt.Errorf("%w: expected a list of styles", ErrBadTheme)
+	}
+
+	styles := make([]style, 0, len(specs))
+
+	for _, spec := range specs {
+		str, ok := spec.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: expected a list of styles", ErrBadTheme)
+		}
+
+		s, err := parseStyle(str)
+		if err != nil {
+			return nil, err
+		}
+
+		styles = append(styles, s)
+	}
+
+	return styles, nil
+}
+
+// applyBorder sets the colors of the border fade from an array of the outer
+// and inner color.
+func applyBorder(theme *Theme, value any) error {
+	colors, ok := value.([]any)
+	if !ok || len(colors) != 2 {
+		return fmt.Errorf("%w: expected two colors", ErrBadTheme)
+	}
+
+	for i, target := range []*paint{&theme.BorderOuter, &theme.BorderInner} {
+		name, ok := colors[i].(string)
+		if !ok {
+			return fmt.Errorf("%w: expected two colors", ErrBadTheme)
+		}
+
+		p, err := parsePaint(name)
+		if err != nil {
+			return err
+		}
+
+		*target = p
+	}
+
+	return nil
+}
+
+// themeElements returns the styles of a theme by their names in theme files.
+// Styles of the json table are prefixed with "json.".
+func themeElements(theme *Theme) map[string]style {
+	return map[string]style{
+		"time":          theme.Time,
+		"key":           theme.Key,
+		"gutter":        theme.Gutter,
+		"stdout":        theme.Stdout,
+		"stderr":        theme.Stderr,
+		"error":         theme.Error,
+		"json.key":      theme.JSON.Key,
+		"json.string":   theme.JSON.String,
+		"json.number":   theme.JSON.Number,
+		"json.true":     theme.JSON.True,
+		"json.false":    theme.JSON.False,
+		"json.null":     theme.JSON.Null,
+		"json.escape":   theme.JSON.Escape,
+		"json.brackets": theme.JSON.Brackets,
+	}
+}
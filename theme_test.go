@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	t.Parallel()
+
+	for name := range builtinThemes {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			theme, err := loadTheme(name)
+			if err != nil {
+				t.Fatalf("loadTheme(%q) failed: %v", name, err)
+			}
+
+			_, err = newLevelTable(theme.levels)
+			if err != nil {
+				t.Errorf("levels of theme %q: %v", name, err)
+			}
+
+			if theme.JSON.String.escape() == "" || theme.Time.escape() == "" {
+				t.Errorf("theme %q leaves elements without a style", name)
+			}
+		})
+	}
+}
+
+func TestThemeFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name    string
+		content string
+		check   func(theme *Theme) bool
+		wantErr bool
+	}{
+		{
+			name:    "Starts from the dark theme",
+			content: `time = "bold #ff8700"`,
+			check: func(theme *Theme) bool {
+				return theme.Time.escape() == "\x1b[1;38;2;255;135;0m" && theme.JSON.String.escape() == "\x1b[32m"
+			},
+		},
+		{
+			name: "Starts from another built-in theme",
+			content: `base = "solarized"
+
+[json]
+string = "black on light-yellow"
+
+[levels.INFO]
+bg = "green"`,
+			check: func(theme *Theme) bool {
+				levels, err := newLevelTable(theme.levels)
+				info, _ := levels.get("info")
+
+				return err == nil &&
+					theme.JSON.String.escape() == "\x1b[30;103m" &&
+					theme.Key.escape() == "\x1b[38;2;147;161;161m" &&
+					info.Background == ansi(colorNames["green"])
+			},
+		},
+		{name: "Unknown element", content: `title = "red"`, wantErr: true},
+		{name: "Unknown color", content: `time = "mauve"`, wantErr: true},
+		{name: "Bad hex color", content: `time = "#12345"`, wantErr: true},
+		{name: "Border needs two colors", content: `border = ["red"]`, wantErr: true},
+		{name: "Unknown base", content: `base = "neon"`, wantErr: true},
+	}
+
+	for i, testCase := range testCases {
+		path := filepath.Join(dir, string(rune('a'+i))+".toml")
+
+		err := os.WriteFile(path, []byte(testCase.content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			theme, err := loadTheme(path)
+			if testCase.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for %q", testCase.content)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !testCase.check(theme) {
+				t.Errorf("theme %q was not applied as expected: %+v", testCase.content, theme)
+			}
+		})
+	}
+}
+
+func TestUnknownTheme(t *testing.T) {
+	t.Parallel()
+
+	_, err := loadTheme("does-not-exist")
+	if err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestSourceStyle(t *testing.T) {
+	t.Parallel()
+
+	theme, err := loadTheme(defaultTheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sourceStyle("api.log", theme).escape() != sourceStyle("api.log", theme).escape() {
+		t.Error("the same source got different styles")
+	}
+
+	seen := map[string]bool{}
+	for _, name := range []string{"api", "db", "worker", "web", "cache", "queue"} {
+		seen[sourceStyle(name, theme).escape()] = true
+	}
+
+	if len(seen) < 2 {
+		t.Error("all sources got the same style")
+	}
+
+	if sourceStyle("api.log", &Theme{Stdout: theme.Stdout}).escape() != theme.Stdout.escape() {
+		t.Error("themes without sources should use the stdout style")
+	}
+}
@@ -32,6 +32,7 @@ var (
 		"Unix":                          unixStrategy,
 		"UnixMicro":                     unixStrategy,
 		"UnixMilli":                     unixStrategy,
+		"UnixNano":                      unixStrategy,
 	}
 )
 
@@ -57,28 +58,27 @@ var ErrUnknownFormat = errors.New("unknown format")
 // ZeroSecond                                 "05"
 // Fractional Seconds (incl. trailing zeros)  ".00" (any amount of digits; up to 9).
 func formatTime(timeStr, inputFormat, outputFormat string) string {
-	var (
-		parsedTime time.Time
-		err        error
-	)
+	parsedTime, err := parseTime(timeStr, inputFormat)
+	if err != nil {
+		return timeStr
+	}
 
+	return parsedTime.Format(outputFormat)
+}
+
+// parseTime parses a time string in inputFormat, which is a name of formats or
+// a Go time layout.
+func parseTime(timeStr, inputFormat string) (time.Time, error) {
 	layout, ok := formats[inputFormat]
 	if !ok {
 		layout = inputFormat
 	}
 
-	switch layout {
-	case unixStrategy:
-		parsedTime, err = parseUnix(inputFormat, timeStr)
-	default:
-		parsedTime, err = time.Parse(layout, timeStr)
+	if layout == unixStrategy {
+		return parseUnix(inputFormat, timeStr)
 	}
 
-	if err != nil {
-		return timeStr
-	}
-
-	return parsedTime.Format(outputFormat)
+	return time.Parse(layout, timeStr)
 }
 
 // parseUnix takes a timestamp string and parses it.
@@ -123,6 +123,14 @@ func parseUnix(format, timestamp string) (time.Time, error) {
 		}
 
 		return time.UnixMicro(i64), nil
+
+	case "UnixNano":
+		i64, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("can not parse timestamp: %w", err)
+		}
+
+		return time.Unix(0, i64), nil
 	}
 
 	return time.Time{}, ErrUnknownFormat
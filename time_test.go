@@ -115,6 +115,13 @@ func TestParseUnix(t *testing.T) {
 			wantTime: time.UnixMicro(1756555555123456),
 			wantErr:  false,
 		},
+		{
+			name:     "UnixNano format - valid",
+			timeStr:  "1756555555123456789",
+			format:   "UnixNano",
+			wantTime: time.Unix(0, 1756555555123456789),
+			wantErr:  false,
+		},
 		{
 			name:     "Error - Unknown format",
 			timeStr:  "1756555555",
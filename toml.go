@@ -0,0 +1,318 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadTOML      = errors.New("invalid TOML")
+	ErrBadTOMLKey   = errors.New("invalid key")
+	ErrBadTOMLValue = errors.New("invalid value")
+	ErrNotATable    = errors.New("not a table")
+)
+
+// parseTOML decodes the subset of TOML that axt's config files need: tables
+// with dotted names, bare or quoted keys, strings, numbers, booleans and arrays
+// of those. Tables become nested map[string]any, numbers int64 or float64 and
+// arrays []any.
+func parseTOML(data string) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+	lines := strings.Split(data, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripTOMLComment(lines[i]))
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name, ok := strings.CutSuffix(strings.TrimPrefix(line, "["), "]")
+			if !ok {
+				return nil, fmt.Errorf("%w: line %d: unterminated table header", ErrBadTOML, lineNo)
+			}
+
+			var err error
+
+			table, err = tomlTable(root, strings.TrimSpace(name))
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrBadTOML, lineNo, err)
+			}
+
+			continue
+		}
+
+		key, rawValue, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d: expected key = value", ErrBadTOML, lineNo)
+		}
+
+		rawValue = strings.TrimSpace(rawValue)
+
+		// Arrays may span several lines.
+		for strings.HasPrefix(rawValue, "[") && !tomlArrayClosed(rawValue) && i+1 < len(lines) {
+			i++
+			rawValue += " " + strings.TrimSpace(stripTOMLComment(lines[i]))
+		}
+
+		name, err := tomlKey(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrBadTOML, lineNo, err)
+		}
+
+		value, rest, err := tomlValue(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrBadTOML, lineNo, err)
+		}
+
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("%w: line %d: unexpected %q", ErrBadTOML, lineNo, rest)
+		}
+
+		if _, exists := table[name]; exists {
+			return nil, fmt.Errorf("%w: line %d: duplicate key %q", ErrBadTOML, lineNo, name)
+		}
+
+		table[name] = value
+	}
+
+	return root, nil
+}
+
+// tomlTable returns the (possibly new) table for a dotted header name.
+func tomlTable(root map[string]any, name string) (map[string]any, error) {
+	table := root
+
+	for _, part := range splitTOMLName(name) {
+		part, err := tomlKey(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+
+		next, exists := table[part]
+		if !exists {
+			next = map[string]any{}
+			table[part] = next
+		}
+
+		nextTable, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%q: %w", part, ErrNotATable)
+		}
+
+		table = nextTable
+	}
+
+	return table, nil
+}
+
+// splitTOMLName splits a dotted name at the dots outside of quoted keys, so
+// [levels."a.b"] is the table a.b in levels.
+func splitTOMLName(name string) []string {
+	var (
+		parts []string
+		quote byte
+		start int
+	)
+
+	for i := 0; i < len(name); i++ {
+		switch {
+		case quote != 0:
+			if name[i] == '\\' && quote == '"' {
+				i++
+			} else if name[i] == quote {
+				quote = 0
+			}
+		case name[i] == '"' || name[i] == '\'':
+			quote = name[i]
+		case name[i] == '.':
+			parts = append(parts, name[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, name[start:])
+}
+
+// tomlKey unquotes a key if necessary.
+func tomlKey(key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("%w: empty", ErrBadTOMLKey)
+	}
+
+	if strings.HasPrefix(key, `"`) || strings.HasPrefix(key, "'") {
+		value, rest, err := tomlString(key)
+		if err != nil {
+			return "", err
+		}
+
+		if rest != "" {
+			return "", fmt.Errorf("%w %q: unexpected %q after it", ErrBadTOMLKey, key, rest)
+		}
+
+		return value, nil
+	}
+
+	for _, char := range key {
+		isBare := char == '_' || char == '-' ||
+			(char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+		if !isBare {
+			return "", fmt.Errorf("%w %q: character %q", ErrBadTOMLKey, key, char)
+		}
+	}
+
+	return key, nil
+}
+
+// tomlValue decodes the value at the start of s and returns the remainder.
+func tomlValue(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", fmt.Errorf("%w: missing", ErrBadTOMLValue)
+	case s[0] == '"' || s[0] == '\'':
+		return tomlString(s)
+	case s[0] == '[':
+		return tomlArray(s)
+	}
+
+	end := strings.IndexAny(s, ", ]")
+	if end == -1 {
+		end = len(s)
+	}
+
+	raw, rest := s[:end], s[end:]
+
+	switch raw {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+
+	cleaned := strings.ReplaceAll(raw, "_", "")
+
+	integer, err := strconv.ParseInt(cleaned, 10, 64)
+	if err == nil {
+		return integer, rest, nil
+	}
+
+	float, err := strconv.ParseFloat(cleaned, 64)
+	if err == nil {
+		return float, rest, nil
+	}
+
+	return nil, "", fmt.Errorf("%w %q", ErrBadTOMLValue, raw)
+}
+
+// tomlString decodes a basic ("...") or literal ('...') string.
+func tomlString(s string) (string, string, error) {
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end == -1 {
+			return "", "", fmt.Errorf("%w: unterminated string %s", ErrBadTOMLValue, s)
+		}
+
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	escaped := false
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("%w: string %s: %w", ErrBadTOMLValue, s[:i+1], err)
+			}
+
+			return value, s[i+1:], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: unterminated string %s", ErrBadTOMLValue, s)
+}
+
+// tomlArray decodes an array of values.
+func tomlArray(s string) ([]any, string, error) {
+	values := []any{}
+	rest := strings.TrimSpace(s[1:])
+
+	for {
+		if strings.HasPrefix(rest, "]") {
+			return values, rest[1:], nil
+		}
+
+		value, remaining, err := tomlValue(rest)
+		if err != nil {
+			return nil, "", err
+		}
+
+		values = append(values, value)
+		rest = strings.TrimSpace(remaining)
+
+		switch {
+		case strings.HasPrefix(rest, ","):
+			rest = strings.TrimSpace(rest[1:])
+		case strings.HasPrefix(rest, "]"):
+		default:
+			return nil, "", fmt.Errorf("%w: unterminated array %s", ErrBadTOMLValue, s)
+		}
+	}
+}
+
+// tomlArrayClosed reports whether the brackets of an array value are balanced.
+func tomlArrayClosed(s string) bool {
+	depth := 0
+
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '[':
+			depth++
+		case s[i] == ']':
+			depth--
+		}
+	}
+
+	return depth <= 0
+}
+
+// stripTOMLComment removes a trailing # comment that is not part of a string.
+func stripTOMLComment(line string) string {
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote != 0:
+			if line[i] == '\\' && quote == '"' {
+				i++
+			} else if line[i] == quote {
+				quote = 0
+			}
+		case line[i] == '"' || line[i] == '\'':
+			quote = line[i]
+		case line[i] == '#':
+			return line[:i]
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected map[string]any
+		wantErr  bool
+	}{
+		{
+			name: "Scalars and comments",
+			input: `# axt config
+time = "@timestamp" # ECS
+emoji = true
+max-line-bytes = 1_000
+ratio = 0.5
+literal = 'C:\logs'`,
+			expected: map[string]any{
+				"time":           "@timestamp",
+				"emoji":          true,
+				"max-line-bytes": int64(1000),
+				"ratio":          0.5,
+				"literal":        `C:\logs`,
+			},
+		},
+		{
+			name: "Arrays across lines",
+			input: `hide = [
+  "trace_id", # noisy
+  "span_id",
+]
+empty = []`,
+			expected: map[string]any{
+				"hide":  []any{"trace_id", "span_id"},
+				"empty": []any{},
+			},
+		},
+		{
+			name: "Dotted tables and quoted keys",
+			input: `[profiles.otel]
+message = "EventName"
+"time-in" = "Unix"
+
+[profiles.ecs]
+level = "log.level"`,
+			expected: map[string]any{
+				"profiles": map[string]any{
+					"otel": map[string]any{"message": "EventName", "time-in": "Unix"},
+					"ecs":  map[string]any{"level": "log.level"},
+				},
+			},
+		},
+		{
+			name: "Quoted table names with dots",
+			input: `[levels."a.b"]
+color = "red"
+
+[levels . 'c.d' . e]
+color = "blue"`,
+			expected: map[string]any{
+				"levels": map[string]any{
+					"a.b": map[string]any{"color": "red"},
+					"c.d": map[string]any{"e": map[string]any{"color": "blue"}},
+				},
+			},
+		},
+		{
+			name:    "Missing value",
+			input:   `time =`,
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate key",
+			input:   "a = 1\na = 2",
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated string",
+			input:   `a = "oops`,
+			wantErr: true,
+		},
+		{
+			name:    "Garbage after value",
+			input:   `a = "b" c`,
+			wantErr: true,
+		},
+		{
+			name:    "Key is not a table",
+			input:   "a = 1\n[a.b]",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := parseTOML(testCase.input)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("parseTOML() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if testCase.wantErr && !errors.Is(err, ErrBadTOML) {
+				t.Errorf("parseTOML() error = %v; want it to wrap ErrBadTOML", err)
+			}
+
+			if !testCase.wantErr && !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("parseTOML() = %#v; want %#v", actual, testCase.expected)
+			}
+		})
+	}
+}
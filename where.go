@@ -0,0 +1,590 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrBadExpression = errors.New("bad expression")
+
+// An expression of --where is built from
+//
+//   - paths to properties: status_code, user.id, @timestamp, /log/level
+//   - literals: 500, -1.5, "text", 'raw text', true, false, null
+//   - comparisons: == != < <= > >= and the regex matches =~ !~
+//   - exists(path)
+//   - && || ! and parentheses
+//
+// Comparisons with a missing property are false. A path on its own is true if
+// the property exists and is not false, null, "" or 0.
+
+// condition is an expression that is true or false for an entry.
+type condition interface {
+	matches(entry *object) bool
+}
+
+// operand is a path or a literal.
+type operand interface {
+	value(entry *object) (any, bool)
+}
+
+type (
+	andCondition struct{ left, right condition }
+	orCondition  struct{ left, right condition }
+	notCondition struct{ inner condition }
+	// existsCondition is exists(path).
+	existsCondition struct{ path string }
+	// truthyCondition is an operand on its own.
+	truthyCondition struct{ operand operand }
+	comparison      struct {
+		left, right operand
+		op          string
+	}
+	matchCondition struct {
+		left    operand
+		pattern *regexp.Regexp
+		negate  bool
+	}
+	pathOperand    string
+	literalOperand struct{ literal any }
+)
+
+func (c andCondition) matches(entry *object) bool {
+	return c.left.matches(entry) && c.right.matches(entry)
+}
+
+func (c orCondition) matches(entry *object) bool {
+	return c.left.matches(entry) || c.right.matches(entry)
+}
+
+func (c notCondition) matches(entry *object) bool {
+	return !c.inner.matches(entry)
+}
+
+func (c existsCondition) matches(entry *object) bool {
+	_, ok := lookup(entry, c.path)
+
+	return ok
+}
+
+func (c truthyCondition) matches(entry *object) bool {
+	value, ok := c.operand.value(entry)
+	if !ok {
+		return false
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	default:
+		return true
+	}
+}
+
+func (c comparison) matches(entry *object) bool {
+	left, ok := c.left.value(entry)
+	if !ok {
+		return false
+	}
+
+	right, ok := c.right.value(entry)
+	if !ok {
+		return false
+	}
+
+	order, comparable := compareValues(left, right)
+
+	switch c.op {
+	case "==":
+		return comparable && order == 0
+	case "!=":
+		return !comparable || order != 0
+	case "<":
+		return comparable && order < 0
+	case "<=":
+		return comparable && order <= 0
+	case ">":
+		return comparable && order > 0
+	case ">=":
+		return comparable && order >= 0
+	default:
+		return false
+	}
+}
+
+func (c matchCondition) matches(entry *object) bool {
+	value, ok := c.left.value(entry)
+	if !ok {
+		return false
+	}
+
+	var text string
+
+	switch v := value.(type) {
+	case string:
+		text = v
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		text = strconv.FormatBool(v)
+	default:
+		return false
+	}
+
+	return c.pattern.MatchString(text) != c.negate
+}
+
+func (p pathOperand) value(entry *object) (any, bool) {
+	return lookup(entry, string(p))
+}
+
+func (l literalOperand) value(*object) (any, bool) {
+	return l.literal, true
+}
+
+// compareValues orders two values. Numbers given as strings, like all values of
+// logfmt, are compared as numbers with numbers. The second return value is
+// false if the values can't be compared.
+func compareValues(left, right any) (int, bool) {
+	if leftNumber, ok := asNumber(left); ok {
+		if rightNumber, ok := asNumber(right); ok {
+			return compareOrdered(leftNumber, rightNumber), true
+		}
+	}
+
+	switch l := left.(type) {
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(l, r), true
+	case bool:
+		r, ok := right.(bool)
+		if !ok || l == r {
+			return 0, ok
+		}
+
+		if l {
+			return 1, true
+		}
+
+		return -1, true
+	case nil:
+		return 0, right == nil
+	default:
+		return 0, false
+	}
+}
+
+func compareOrdered(left, right float64) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// asNumber returns a number or a string that holds a number as float64.
+func asNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(number) {
+			return 0, false
+		}
+
+		return number, true
+	default:
+		return 0, false
+	}
+}
+
+// whereFilter holds the compiled --where and --where-not expressions. An entry
+// has to match all of them.
+type whereFilter []condition
+
+// newWhereFilter compiles the expressions of --where and the negated ones of
+// --where-not.
+func newWhereFilter(where, whereNot []string) (whereFilter, error) {
+	filter := make(whereFilter, 0, len(where)+len(whereNot))
+
+	for i, expressions := range [][]string{where, whereNot} {
+		flagName := "--where"
+		if i == 1 {
+			flagName = "--where-not"
+		}
+
+		for _, expression := range expressions {
+			cond, err := parseExpression(expression)
+			if err != nil {
+				return nil, fmt.Errorf("%s %q: %w", flagName, expression, err)
+			}
+
+			if i == 1 {
+				cond = notCondition{inner: cond}
+			}
+
+			filter = append(filter, cond)
+		}
+	}
+
+	return filter, nil
+}
+
+// allows reports whether an entry matches every expression.
+func (f whereFilter) allows(entry *object) bool {
+	for _, cond := range f {
+		if !cond.matches(entry) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// token kinds of expressions.
+const (
+	tokenEnd = iota
+	tokenPath
+	tokenNumber
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	kind int
+	text string
+	// value holds the parsed number or string.
+	value any
+	pos   int
+}
+
+// operators are sorted so that longer ones are found first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+
+// tokenize splits an expression into tokens.
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+
+	for pos := 0; pos < len(expression); {
+		char := rune(expression[pos])
+
+		switch {
+		case unicode.IsSpace(char):
+			pos++
+		case char == '"' || char == '\'':
+			end := pos + 1
+			for end < len(expression) && expression[end] != byte(char) {
+				if expression[end] == '\\' && char == '"' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(expression) {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrBadExpression, pos)
+			}
+
+			text := expression[pos : end+1]
+			str := text[1 : len(text)-1]
+
+			if char == '"' {
+				var err error
+
+				str, err = strconv.Unquote(text)
+				if err != nil {
+					return nil, fmt.Errorf("%w: bad string at %d", ErrBadExpression, pos)
+				}
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: text, value: str, pos: pos})
+			pos = end + 1
+		case unicode.IsDigit(char) || char == '-' && pos+1 < len(expression) && unicode.IsDigit(rune(expression[pos+1])):
+			end := pos + 1
+			for end < len(expression) && strings.ContainsRune("0123456789.eE+-_", rune(expression[end])) {
+				end++
+			}
+
+			number, err := strconv.ParseFloat(strings.ReplaceAll(expression[pos:end], "_", ""), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad number %q at %d", ErrBadExpression, expression[pos:end], pos)
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: expression[pos:end], value: number, pos: pos})
+			pos = end
+		case isPathStart(char):
+			end := pos
+			for end < len(expression) && (isPathStart(rune(expression[end])) || unicode.IsDigit(rune(expression[end])) ||
+				strings.ContainsRune(".-\\", rune(expression[end]))) {
+				if expression[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			end = min(end, len(expression))
+			tokens = append(tokens, token{kind: tokenPath, text: expression[pos:end], pos: pos})
+			pos = end
+		default:
+			found := false
+
+			for _, op := range operators {
+				if strings.HasPrefix(expression[pos:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: pos})
+					pos += len(op)
+					found = true
+
+					break
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrBadExpression, char, pos)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd, pos: len(expression)}), nil
+}
+
+// isPathStart reports whether a path can start with a byte. Bytes of
+// multi-byte characters are part of paths.
+func isPathStart(char rune) bool {
+	return unicode.IsLetter(char) || char == '_' || char == '@' || char == '$' || char == '/' || char >= utf8.RuneSelf
+}
+
+// expressionParser is a recursive descent parser for expressions.
+type expressionParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseExpression compiles an expression of --where.
+func parseExpression(expression string) (condition, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens}
+
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tokenEnd {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrBadExpression, next.text, next.pos)
+	}
+
+	return cond, nil
+}
+
+func (p *expressionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *expressionParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.text == op {
+		p.pos++
+
+		return true
+	}
+
+	return false
+}
+
+func (p *expressionParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orCondition{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseAnd() (condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andCondition{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseUnary() (condition, error) {
+	if p.accept("!") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notCondition{inner: inner}, nil
+	}
+
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.accept(")") {
+			return nil, p.unexpected("\")\"")
+		}
+
+		return inner, nil
+	}
+
+	if t := p.peek(); t.kind == tokenPath && t.text == "exists" {
+		p.next()
+
+		if !p.accept("(") {
+			return nil, p.unexpected("\"(\"")
+		}
+
+		path := p.next()
+		if path.kind != tokenPath && path.kind != tokenString {
+			return nil, fmt.Errorf("%w: expected a path at %d", ErrBadExpression, path.pos)
+		}
+
+		if !p.accept(")") {
+			return nil, p.unexpected("\")\"")
+		}
+
+		name := path.text
+		if path.kind == tokenString {
+			name, _ = path.value.(string)
+		}
+
+		return existsCondition{path: name}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *expressionParser) parseComparison() (condition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return truthyCondition{operand: left}, nil
+	}
+
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		return comparison{left: left, right: right, op: t.text}, nil
+	case "=~", "!~":
+		p.next()
+
+		pattern := p.next()
+
+		text, ok := pattern.value.(string)
+		if pattern.kind != tokenString || !ok {
+			return nil, fmt.Errorf("%w: expected a regular expression in quotes at %d", ErrBadExpression, pattern.pos)
+		}
+
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBadExpression, err)
+		}
+
+		return matchCondition{left: left, pattern: re, negate: t.text == "!~"}, nil
+	default:
+		return truthyCondition{operand: left}, nil
+	}
+}
+
+func (p *expressionParser) parseOperand() (operand, error) {
+	t := p.peek()
+
+	switch t.kind {
+	case tokenNumber, tokenString:
+		p.next()
+
+		return literalOperand{literal: t.value}, nil
+	case tokenPath:
+		p.next()
+
+		switch t.text {
+		case "true":
+			return literalOperand{literal: true}, nil
+		case "false":
+			return literalOperand{literal: false}, nil
+		case "null":
+			return literalOperand{literal: nil}, nil
+		default:
+			return pathOperand(t.text), nil
+		}
+	default:
+		return nil, p.unexpected("a property or value")
+	}
+}
+
+// unexpected returns an error for the next token.
+func (p *expressionParser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == tokenEnd {
+		return fmt.Errorf("%w: expected %s at the end", ErrBadExpression, expected)
+	}
+
+	return fmt.Errorf("%w: expected %s at %d, got %q", ErrBadExpression, expected, t.pos, t.text)
+}
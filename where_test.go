@@ -0,0 +1,113 @@
+package main
+
+import "testing"
+
+func TestWhere(t *testing.T) {
+	t.Parallel()
+
+	entry, err := parseObject(`{"status_code":503,"path":"/api/users","user":{"id":12345,"name":"Ann"},"ok":false,` +
+		`"tags":["a","b"],"log.level":"warn","count":"42","empty":"","nothing":null,"größe":3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		expression string
+		expected   bool
+	}{
+		{expression: `status_code >= 500`, expected: true},
+		{expression: `status_code < 500`, expected: false},
+		{expression: `status_code == 503 && path =~ "^/api"`, expected: true},
+		{expression: `path !~ '^/api'`, expected: false},
+		{expression: `user.id == 12345`, expected: true},
+		{expression: `/user/name == "Ann"`, expected: true},
+		{expression: `user.name != "Bob"`, expected: true},
+		{expression: `tags.1 == "b"`, expected: true},
+		{expression: `log.level == "warn"`, expected: true},
+		{expression: `count > 40`, expected: true},
+		{expression: `ok == false`, expected: true},
+		{expression: `ok`, expected: false},
+		{expression: `!ok`, expected: true},
+		{expression: `empty || nothing`, expected: false},
+		{expression: `nothing == null`, expected: true},
+		{expression: `exists(user.name) && !exists(user.email)`, expected: true},
+		{expression: `missing == 1 || missing != 1`, expected: false},
+		{expression: `(status_code < 500 || ok == false) && user.id > 1e4`, expected: true},
+		{expression: `status_code == "503"`, expected: true},
+		{expression: `path > "/api"`, expected: true},
+		{expression: `größe == 3`, expected: true},
+		{expression: `status_code =~ "^5\\d\\d$"`, expected: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expression, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := parseExpression(testCase.expression)
+			if err != nil {
+				t.Fatalf("parseExpression(%q) failed: %v", testCase.expression, err)
+			}
+
+			if actual := cond.matches(entry); actual != testCase.expected {
+				t.Errorf("%s = %v; want %v", testCase.expression, actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestWhereErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expression := range []string{
+		``,
+		`status_code >=`,
+		`(a == 1`,
+		`a == 1)`,
+		`path =~ ^/api`,
+		`msg =~ 1`,
+		`path =~ "("`,
+		`name == "unterminated`,
+		`a # b`,
+		`exists(a`,
+		`a == 1 b == 2`,
+	} {
+		t.Run(expression, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseExpression(expression)
+			if err == nil {
+				t.Errorf("expected an error for %q", expression)
+			}
+		})
+	}
+}
+
+func TestWhereFilter(t *testing.T) {
+	t.Parallel()
+
+	filter, err := newWhereFilter([]string{`level == "error"`, `code >= 500`}, []string{`path == "/health"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		line     string
+		expected bool
+	}{
+		{line: `{"level":"error","code":500,"path":"/api"}`, expected: true},
+		{line: `{"level":"error","code":500,"path":"/health"}`, expected: false},
+		{line: `{"level":"error","code":404,"path":"/api"}`, expected: false},
+		{line: `{"level":"info","code":500}`, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		entry, err := parseObject(testCase.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := filter.allows(entry); actual != testCase.expected {
+			t.Errorf("allows(%s) = %v; want %v", testCase.line, actual, testCase.expected)
+		}
+	}
+}